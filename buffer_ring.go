@@ -0,0 +1,209 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+const maxBufferRingEntries = 1 << 15
+
+var _ ProvidedBuffers = &BufferRing{}
+
+// BufferRing is a group of buffers provided through a ring shared with the kernel,
+// a released buffer is given back by updating the tail of the ring without any request
+type BufferRing struct {
+	iour *IOURing
+
+	id          uint16
+	size        int
+	incremental bool
+
+	lock    sync.Mutex
+	mem     []byte
+	ring    []iouring_syscall.IOURingBuf
+	mask    uint16
+	tail    uint16
+	buffers []byte
+
+	// consumed bytes of the buffers, only used by incremental consumption
+	offsets []int
+}
+
+// RegisterBufferRing registers a buffer ring of entries buffers of size bytes under the group id,
+// entries must be a power of 2 and no more than 32768.
+// With iouring_syscall.IOU_PBUF_RING_INC flag, a buffer may be consumed by multiple results,
+// the flag is dropped if the kernel does not support incremental consumption
+// Available since 5.19
+func (iour *IOURing) RegisterBufferRing(id uint16, entries int, size int, flags uint16) (*BufferRing, error) {
+	if entries <= 0 || entries > maxBufferRingEntries || entries&(entries-1) != 0 {
+		return nil, errors.New("buffer ring entries must be a power of 2 and no more than 32768")
+	}
+	if size <= 0 {
+		return nil, errors.New("invalid buffer size")
+	}
+
+	mem, err := syscall.Mmap(
+		-1,
+		0,
+		entries*int(unsafe.Sizeof(iouring_syscall.IOURingBuf{})),
+		syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_ANON|syscall.MAP_PRIVATE,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &BufferRing{
+		iour:    iour,
+		id:      id,
+		size:    size,
+		mem:     mem,
+		ring:    (*[maxBufferRingEntries]iouring_syscall.IOURingBuf)(unsafe.Pointer(&mem[0]))[:entries:entries],
+		mask:    uint16(entries - 1),
+		buffers: make([]byte, entries*size),
+	}
+
+	reg := iouring_syscall.IOURingBufReg{
+		RingAddr:    uint64(uintptr(unsafe.Pointer(&mem[0]))),
+		RingEntries: uint32(entries),
+		Bgid:        id,
+		Flags:       flags,
+	}
+	err = iouring_syscall.IOURingRegister(iour.fd, iouring_syscall.IORING_REGISTER_PBUF_RING, unsafe.Pointer(&reg), 1)
+	if err != nil && flags&iouring_syscall.IOU_PBUF_RING_INC != 0 && errors.Is(err, syscall.EINVAL) {
+		reg.Flags &^= iouring_syscall.IOU_PBUF_RING_INC
+		err = iouring_syscall.IOURingRegister(iour.fd, iouring_syscall.IORING_REGISTER_PBUF_RING, unsafe.Pointer(&reg), 1)
+	}
+	if err != nil {
+		syscall.Munmap(mem)
+		return nil, err
+	}
+
+	if reg.Flags&iouring_syscall.IOU_PBUF_RING_INC != 0 {
+		ring.incremental = true
+		ring.offsets = make([]int, entries)
+	}
+
+	ring.lock.Lock()
+	for bid := 0; bid < entries; bid++ {
+		ring.add(uint16(bid))
+	}
+	ring.publish()
+	ring.lock.Unlock()
+
+	return ring, nil
+}
+
+func (ring *BufferRing) ID() uint16 {
+	return ring.id
+}
+
+func (ring *BufferRing) BufferSize() int {
+	return ring.size
+}
+
+// Incremental reports whether the buffers are consumed incrementally
+func (ring *BufferRing) Incremental() bool {
+	return ring.incremental
+}
+
+// Buffer returns the buffer by buffer id
+func (ring *BufferRing) Buffer(bid uint16) []byte {
+	offset := int(bid) * ring.size
+	return ring.buffers[offset : offset+ring.size : offset+ring.size]
+}
+
+// ReleaseBuffer gives the buffer back to the kernel by the tail of the ring
+func (ring *BufferRing) ReleaseBuffer(bid uint16) error {
+	if int(bid) > int(ring.mask) {
+		return errors.New("invalid buffer id")
+	}
+
+	ring.lock.Lock()
+	defer ring.lock.Unlock()
+
+	if ring.mem == nil {
+		return errors.New("buffer ring is unregistered")
+	}
+
+	ring.add(bid)
+	ring.publish()
+	return nil
+}
+
+// Unregister unregisters the buffer ring from the kernel
+func (ring *BufferRing) Unregister() error {
+	ring.lock.Lock()
+	defer ring.lock.Unlock()
+
+	if ring.mem == nil {
+		return nil
+	}
+
+	reg := iouring_syscall.IOURingBufReg{Bgid: ring.id}
+	if err := iouring_syscall.IOURingRegister(
+		ring.iour.fd,
+		iouring_syscall.IORING_UNREGISTER_PBUF_RING,
+		unsafe.Pointer(&reg), 1,
+	); err != nil {
+		return err
+	}
+
+	ring.ring = nil
+	err := syscall.Munmap(ring.mem)
+	ring.mem = nil
+	return err
+}
+
+func (ring *BufferRing) add(bid uint16) {
+	buf := &ring.ring[ring.tail&ring.mask]
+	buf.Addr = uint64(uintptr(unsafe.Pointer(&ring.Buffer(bid)[0])))
+	buf.Len = uint32(ring.size)
+	buf.Bid = bid
+	ring.tail++
+}
+
+// publish makes the added buffers visible to the kernel,
+// the tail shares a 32-bit word with the buffer id of the first entry
+func (ring *BufferRing) publish() {
+	word := [2]uint16{ring.ring[0].Bid, ring.tail}
+	atomic.StoreUint32((*uint32)(unsafe.Pointer(&ring.ring[0].Bid)), *(*uint32)(unsafe.Pointer(&word)))
+}
+
+// selectBuffer is called in the order of the completion events,
+// so the consumed bytes of the incremental buffers are tracked without lock
+func (ring *BufferRing) selectBuffer(flags uint32, res int32) []byte {
+	bid := uint16(flags >> iouring_syscall.IORING_CQE_BUFFER_SHIFT)
+	b := ring.Buffer(bid)
+	if res < 0 {
+		return b[:0]
+	}
+
+	if !ring.incremental {
+		return b[:res]
+	}
+
+	offset := ring.offsets[bid]
+	if flags&iouring_syscall.IORING_CQE_F_BUF_MORE != 0 {
+		ring.offsets[bid] += int(res)
+	} else {
+		ring.offsets[bid] = 0
+	}
+	return b[offset : offset+int(res)]
+}
+
+// releaseSelected gives the buffer back only if the kernel has finished with it
+func (ring *BufferRing) releaseSelected(flags uint32) error {
+	if flags&iouring_syscall.IORING_CQE_F_BUF_MORE != 0 {
+		return nil
+	}
+	return ring.ReleaseBuffer(uint16(flags >> iouring_syscall.IORING_CQE_BUFFER_SHIFT))
+}
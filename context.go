@@ -0,0 +1,98 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"context"
+	"sync/atomic"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// SubmitRequestCtx is like SubmitRequest, but the request is canceled when the ctx is done,
+// the error of the canceled request is ContextError, which wraps the error of the ctx
+func (iour *IOURing) SubmitRequestCtx(ctx context.Context, request PrepRequest, ch chan<- Result) (Request, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	req, err := iour.SubmitRequest(withContext(ctx, request), ch)
+	if err != nil {
+		return nil, err
+	}
+
+	iour.watchContext(ctx, req.Done(), []Request{req})
+	return req, nil
+}
+
+// SubmitRequestsCtx is like SubmitRequests, but the uncompleted requests are canceled when the ctx is done
+func (iour *IOURing) SubmitRequestsCtx(ctx context.Context, requests []PrepRequest, ch chan<- Result) (RequestSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	set, err := iour.SubmitRequests(withContexts(ctx, requests), ch)
+	if err != nil {
+		return nil, err
+	}
+
+	iour.watchContext(ctx, set.Done(), set.Requests())
+	return set, nil
+}
+
+// SubmitLinkRequestsCtx is like SubmitLinkRequests, but the uncompleted requests are canceled when the ctx is done
+func (iour *IOURing) SubmitLinkRequestsCtx(ctx context.Context, requests []PrepRequest, ch chan<- Result) (RequestSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	set, err := iour.SubmitLinkRequests(withContexts(ctx, requests), ch)
+	if err != nil {
+		return nil, err
+	}
+
+	iour.watchContext(ctx, set.Done(), set.Requests())
+	return set, nil
+}
+
+func withContext(ctx context.Context, prepReq PrepRequest) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		prepReq(sqe, userData)
+		userData.request.ctx = ctx
+	}
+}
+
+func withContexts(ctx context.Context, prepReqs []PrepRequest) []PrepRequest {
+	reqs := make([]PrepRequest, len(prepReqs))
+	for i := range prepReqs {
+		reqs[i] = withContext(ctx, prepReqs[i])
+	}
+	return reqs
+}
+
+func (iour *IOURing) watchContext(ctx context.Context, done <-chan struct{}, requests []Request) {
+	if ctx.Done() == nil {
+		return
+	}
+
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+
+		for _, r := range requests {
+			req := r.(*request)
+			if req.isDone() {
+				continue
+			}
+
+			// the flag must be set before the cancel request is submitted,
+			// the error of the request is resolved after it is completed
+			atomic.StoreInt32(&req.ctxCanceled, 1)
+			_, _ = iour.submitCancel(req.id)
+		}
+	}()
+}
@@ -0,0 +1,99 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"unsafe"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// continuation keeps the prepared entry of the request,
+// the entry is resubmitted for the remaining buffer of the short read or write
+type continuation struct {
+	sqe iouring_syscall.SubmissionQueueEntry
+
+	// n is the number of bytes transferred by the previous completion events
+	n int32
+}
+
+// WithContinuation the short read or write is resubmitted for the remaining range of the buffer
+// until the buffer is completed, the end of file or an error, and the result reports the total
+// byte count. If the request fails after partial progress, Result.ReturnValue1 reports
+// the byte count transferred before the error. In a link chain, the short read or write
+// still breaks the link in the kernel, the following requests are canceled.
+// It only works with the Read, Write, Pread and Pwrite requests
+func (prepReq PrepRequest) WithContinuation() PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		prepReq(sqe, userData)
+
+		switch sqe.Opcode() {
+		case iouring_syscall.IORING_OP_READ, iouring_syscall.IORING_OP_WRITE:
+			userData.request.cont = &continuation{}
+		}
+	}
+}
+
+// ReadFull reads len(b) bytes from the offset of the fd,
+// the result is less than len(b) only at the end of file
+func ReadFull(fd int, b []byte, offset uint64) PrepRequest {
+	return Pread(fd, b, offset).WithContinuation()
+}
+
+// WriteAll writes all the data at the offset of the fd unless an error occurs
+func WriteAll(fd int, b []byte, offset uint64) PrepRequest {
+	return Pwrite(fd, b, offset).WithContinuation()
+}
+
+// next records the result of the completion event, and returns the entry
+// for the remaining buffer, it returns nil if the request is completed
+func (cont *continuation) next(req *request, res int32) iouring_syscall.SubmissionQueueEntry {
+	if res <= 0 || int(cont.n+res) >= len(req.b0) {
+		return nil
+	}
+	cont.n += res
+	b := req.b0[cont.n:]
+
+	// offset -1 means the current file position
+	offset := req.offset
+	if offset != ^uint64(0) {
+		offset += uint64(cont.n)
+	}
+
+	sqe := req.iour.sq.sqes.copyEntry(cont.sqe)
+	sqe.PrepOperation(sqe.Opcode(), sqe.Fd(), uint64(uintptr(unsafe.Pointer(&b[0]))), uint32(len(b)), offset)
+
+	// the links of the request are broken by the short read or write
+	sqe.CleanFlags(iouring_syscall.IOSQE_FLAGS_IO_LINK | iouring_syscall.IOSQE_FLAGS_IO_HARDLINK | iouring_syscall.IOSQE_FLAGS_IO_DRAIN)
+	return sqe
+}
+
+// transferred returns the total byte count of the request completed by the result
+func (cont *continuation) transferred(res int32) int {
+	if res < 0 {
+		return int(cont.n)
+	}
+	return int(cont.n + res)
+}
+
+// resubmit submits the entry of the in-flight request, the request is aborted
+// if it is canceled between the completion event and the resubmission,
+// or the entry can not be submitted
+func (iour *IOURing) resubmit(userData *UserData, sqe iouring_syscall.SubmissionQueueEntry) {
+	// the flag is checked under the submitLock, so the cancel request
+	// is submitted after the entry if the flag is set later
+	iour.submitLock.Lock()
+	err := iour.checkSubmit()
+	if err == nil && userData.request.isCanceled() {
+		err = ErrRequestCanceled
+	}
+	if err == nil {
+		err = iour.submitStage([]iouring_syscall.SubmissionQueueEntry{sqe})
+	}
+	iour.submitLock.Unlock()
+
+	if err != nil {
+		iour.abortRequests([]*UserData{userData})
+	}
+}
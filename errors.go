@@ -2,16 +2,61 @@
 
 package iouring
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrIOURingClosed = errors.New("iouring closed")
+	ErrIOURingClosed   = errors.New("iouring closed")
+	ErrIOURingDisabled = errors.New("iouring is disabled")
+	ErrRawMode         = errors.New("iouring is in raw mode")
+	ErrNotRawMode      = errors.New("iouring is not in raw mode")
+
+	ErrFeatureNotSupported = errors.New("feature is not supported by the kernel")
+	ErrWaitTimeout         = errors.New("wait timeout")
 
 	ErrRequestCanceled     = errors.New("request is canceled")
 	ErrRequestNotFound     = errors.New("request is not found")
 	ErrRequestCompleted    = errors.New("request has already been completed")
 	ErrRequestNotCompleted = errors.New("request is not completed")
 	ErrNoRequestCallback   = errors.New("no request callback")
+	ErrLinkChainTooLong    = errors.New("link chain is longer than the submission queue")
+	ErrControlTruncated    = errors.New("control message is truncated")
 
 	ErrUnregisteredFile = errors.New("file is unregistered")
+	ErrNoFixedBuffer    = errors.New("no free fixed buffer")
+	ErrOpNotSupported   = errors.New("opcode is not supported")
 )
+
+// OpNotSupportedError is returned when the opcode of the request is not supported by the running kernel,
+// it matches ErrOpNotSupported by errors.Is
+type OpNotSupportedError struct {
+	Opcode uint8
+}
+
+func (e *OpNotSupportedError) Error() string {
+	return fmt.Sprintf("opcode %d is not supported by the kernel", e.Opcode)
+}
+
+func (e *OpNotSupportedError) Is(target error) bool {
+	return target == ErrOpNotSupported
+}
+
+// ContextError is the error of the request canceled by the context,
+// it matches ErrRequestCanceled and the error of the context by errors.Is
+type ContextError struct {
+	Err error
+}
+
+func (e *ContextError) Error() string {
+	return ErrRequestCanceled.Error() + ": " + e.Err.Error()
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ContextError) Is(target error) bool {
+	return target == ErrRequestCanceled
+}
@@ -16,11 +16,36 @@ func (iour *IOURing) registerEventfd() error {
 	if err != nil {
 		return os.NewSyscallError("eventfd", err)
 	}
-	iour.eventfd = eventfd
 
+	// non-blocking file is added to the netpoller
+	iour.eventfd = os.NewFile(uintptr(eventfd), "iouring-eventfd")
+	if iour.eventfdConn, err = iour.eventfd.SyscallConn(); err != nil {
+		return err
+	}
+
+	fd := int32(eventfd)
 	return iouring_syscall.IOURingRegister(
 		iour.fd,
 		iouring_syscall.IORING_REGISTER_EVENTFD,
-		unsafe.Pointer(&iour.eventfd), 1,
+		unsafe.Pointer(&fd), 1,
 	)
 }
+
+// waitEventfd waits until the eventfd is notified, and resets the counter of eventfd
+func (iour *IOURing) waitEventfd() error {
+	var buf [8]byte
+	return iour.eventfdConn.Read(func(fd uintptr) bool {
+		_, err := unix.Read(int(fd), buf[:])
+		return err != unix.EAGAIN
+	})
+}
+
+// notifyEventfd wakes up the background goroutine waiting for the eventfd
+func (iour *IOURing) notifyEventfd() {
+	var buf [8]byte
+	*(*uint64)(unsafe.Pointer(&buf[0])) = 1
+	iour.eventfdConn.Write(func(fd uintptr) bool {
+		unix.Write(int(fd), buf[:])
+		return true
+	})
+}
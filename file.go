@@ -0,0 +1,409 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// File is the file whose IO is driven by the ring, it implements io.Reader, io.Writer,
+// io.ReaderAt, io.WriterAt, io.Seeker and io.Closer
+type File struct {
+	fd     int
+	name   string
+	append bool
+	ring   *IOURing
+
+	// mu protects the offset used by Read, Write and Seek
+	mu     sync.Mutex
+	offset int64
+
+	// closeMu is held for reading until the request of the fd is submitted,
+	// and for writing by Close, so the fd is not reused by the requests after Close
+	closeMu   sync.RWMutex
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var (
+	_ io.ReadWriteSeeker = &File{}
+	_ io.ReaderAt        = &File{}
+	_ io.WriterAt        = &File{}
+	_ io.Closer          = &File{}
+)
+
+// OpenFile opens the named file by the ring, the flag and perm are the same as os.OpenFile
+func (iour *IOURing) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	prep, err := Openat(unix.AT_FDCWD, name, uint32(flag|syscall.O_CLOEXEC), syscallMode(perm))
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	result, err := iour.do(prep)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	fd, _ := result.ReturnFd()
+	file := iour.NewFile(fd, name)
+	file.append = flag&os.O_APPEND != 0
+	return file, nil
+}
+
+// NewFile returns the File with the file descriptor, the IO of the file is driven by the ring
+// and the file descriptor is owned by the File
+func (iour *IOURing) NewFile(fd int, name string) *File {
+	return &File{fd: fd, name: name, ring: iour, closed: make(chan struct{})}
+}
+
+// do submits the request and waits the result
+func (iour *IOURing) do(prep PrepRequest) (Result, error) {
+	ch := make(chan Result, 1)
+	if _, err := iour.SubmitRequest(prep, ch); err != nil {
+		return nil, err
+	}
+
+	result := <-ch
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Name returns the name of the file
+func (f *File) Name() string {
+	return f.name
+}
+
+// Fd returns the file descriptor of the file
+func (f *File) Fd() int {
+	return f.fd
+}
+
+// Read reads data from the current offset of the file by the ring
+func (f *File) Read(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, err := f.pread(b, f.offset)
+	f.offset += int64(n)
+	if err != nil {
+		return n, f.pathError("read", err)
+	}
+	if n == 0 && len(b) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// ReadAt reads len(b) bytes from the offset of the file by the ring,
+// it returns the error if fewer bytes are read, the error is io.EOF at end of file
+func (f *File) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, f.pathError("readat", errors.New("negative offset"))
+	}
+
+	var read int
+	for read < len(b) {
+		n, err := f.pread(b[read:], off+int64(read))
+		if err != nil {
+			return read, f.pathError("readat", err)
+		}
+		if n == 0 {
+			return read, io.EOF
+		}
+		read += n
+	}
+	return read, nil
+}
+
+// Write writes data at the current offset of the file by the ring,
+// the data is appended to the end of the file if the file is opened with O_APPEND
+func (f *File) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.append {
+		// the kernel uses and updates the file position for offset -1,
+		// and the offset follows the file position like os.File
+		n, err := f.writeAll("write", b, -1)
+		if n > 0 {
+			f.closeMu.RLock()
+			if !f.isClosed() {
+				if offset, serr := unix.Seek(f.fd, 0, io.SeekCurrent); serr == nil {
+					f.offset = offset
+				}
+			}
+			f.closeMu.RUnlock()
+		}
+		return n, err
+	}
+
+	n, err := f.writeAll("write", b, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// WriteAt writes all the data at the offset of the file by the ring
+func (f *File) WriteAt(b []byte, off int64) (int, error) {
+	if f.append {
+		return 0, errors.New("iouring: invalid use of WriteAt on file opened with O_APPEND")
+	}
+	if off < 0 {
+		return 0, f.pathError("writeat", errors.New("negative offset"))
+	}
+	return f.writeAll("writeat", b, off)
+}
+
+func (f *File) writeAll(op string, b []byte, off int64) (int, error) {
+	var written int
+	for written < len(b) {
+		offset := off
+		if off >= 0 {
+			offset += int64(written)
+		}
+
+		n, err := f.pwrite(b[written:], offset)
+		if err != nil {
+			return written, f.pathError(op, err)
+		}
+		if n == 0 {
+			return written, f.pathError(op, io.ErrShortWrite)
+		}
+		written += n
+	}
+	return written, nil
+}
+
+func (f *File) pread(b []byte, off int64) (int, error) {
+	if len(b) == 0 {
+		if f.isClosed() {
+			return 0, os.ErrClosed
+		}
+		return 0, nil
+	}
+
+	result, err := f.do(Pread(f.fd, b, uint64(off)))
+	if err != nil {
+		return 0, err
+	}
+	return result.ReturnInt()
+}
+
+func (f *File) pwrite(b []byte, off int64) (int, error) {
+	result, err := f.do(Pwrite(f.fd, b, uint64(off)))
+	if err != nil {
+		return 0, err
+	}
+	return result.ReturnInt()
+}
+
+// Seek sets the offset for the next Read or Write, the whence is the same as io.Seeker
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.isClosed() {
+		return 0, f.pathError("seek", os.ErrClosed)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		stat, err := f.statx()
+		if err != nil {
+			return 0, f.pathError("seek", err)
+		}
+		offset += int64(stat.Size)
+	default:
+		return 0, f.pathError("seek", syscall.EINVAL)
+	}
+
+	if offset < 0 {
+		return 0, f.pathError("seek", syscall.EINVAL)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Sync commits the content of the file to the disk by the ring
+func (f *File) Sync() error {
+	if _, err := f.do(Fsync(f.fd)); err != nil {
+		return f.pathError("sync", err)
+	}
+	return nil
+}
+
+// Stat returns the FileInfo of the file by the statx request,
+// the Sys method of the FileInfo returns *unix.Statx_t
+func (f *File) Stat() (os.FileInfo, error) {
+	stat, err := f.statx()
+	if err != nil {
+		return nil, f.pathError("stat", err)
+	}
+	return newFileInfo(f.name, stat), nil
+}
+
+func (f *File) statx() (*unix.Statx_t, error) {
+	stat := &unix.Statx_t{}
+	prep, err := Statx(f.fd, "", unix.AT_EMPTY_PATH, unix.STATX_BASIC_STATS, stat)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.do(prep); err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+// Truncate changes the size of the file, it's done by the ring if the kernel supports
+// the ftruncate request, otherwise by the ftruncate syscall. The offset is not changed
+func (f *File) Truncate(size int64) error {
+	var err error
+	if f.ring.IsSupported(OpFtruncate) {
+		_, err = f.do(Ftruncate(f.fd, size))
+	} else {
+		f.closeMu.RLock()
+		err = os.ErrClosed
+		if !f.isClosed() {
+			err = syscall.Ftruncate(f.fd, size)
+		}
+		f.closeMu.RUnlock()
+	}
+	if err != nil {
+		return f.pathError("truncate", err)
+	}
+	return nil
+}
+
+// Close closes the file by the ring
+func (f *File) Close() error {
+	err := os.ErrClosed
+	f.closeOnce.Do(func() {
+		close(f.closed)
+
+		// wait for the requests which are being submitted with the fd
+		f.closeMu.Lock()
+		defer f.closeMu.Unlock()
+		if _, err = f.ring.do(Close(f.fd)); err != nil {
+			if errors.Is(err, ErrIOURingClosed) {
+				err = syscall.Close(f.fd)
+			}
+		}
+	})
+	if err != nil {
+		return f.pathError("close", err)
+	}
+	return nil
+}
+
+// do submits the request of the fd and waits the result,
+// the fd is not closed by Close until the request is submitted
+func (f *File) do(prep PrepRequest) (Result, error) {
+	f.closeMu.RLock()
+	if f.isClosed() {
+		f.closeMu.RUnlock()
+		return nil, os.ErrClosed
+	}
+
+	ch := make(chan Result, 1)
+	_, err := f.ring.SubmitRequest(prep, ch)
+	f.closeMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-ch
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (f *File) isClosed() bool {
+	select {
+	case <-f.closed:
+		return true
+	default:
+	}
+	return false
+}
+
+func (f *File) pathError(op string, err error) error {
+	return &os.PathError{Op: op, Path: f.name, Err: err}
+}
+
+// fileInfo is the os.FileInfo built from the statx result
+type fileInfo struct {
+	name string
+	stat *unix.Statx_t
+}
+
+func newFileInfo(name string, stat *unix.Statx_t) *fileInfo {
+	return &fileInfo{name: filepath.Base(name), stat: stat}
+}
+
+func (fi *fileInfo) Name() string { return fi.name }
+func (fi *fileInfo) Size() int64  { return int64(fi.stat.Size) }
+func (fi *fileInfo) IsDir() bool  { return fi.Mode().IsDir() }
+func (fi *fileInfo) Sys() interface{} {
+	return fi.stat
+}
+
+func (fi *fileInfo) ModTime() time.Time {
+	return time.Unix(fi.stat.Mtime.Sec, int64(fi.stat.Mtime.Nsec))
+}
+
+func (fi *fileInfo) Mode() os.FileMode {
+	mode := os.FileMode(fi.stat.Mode & 0777)
+	switch fi.stat.Mode & syscall.S_IFMT {
+	case syscall.S_IFBLK:
+		mode |= os.ModeDevice
+	case syscall.S_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case syscall.S_IFDIR:
+		mode |= os.ModeDir
+	case syscall.S_IFIFO:
+		mode |= os.ModeNamedPipe
+	case syscall.S_IFLNK:
+		mode |= os.ModeSymlink
+	case syscall.S_IFSOCK:
+		mode |= os.ModeSocket
+	}
+	if fi.stat.Mode&syscall.S_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+	if fi.stat.Mode&syscall.S_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+	if fi.stat.Mode&syscall.S_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// syscallMode converts the os.FileMode to the mode of open(2)
+func syscallMode(perm os.FileMode) uint32 {
+	mode := uint32(perm.Perm())
+	if perm&os.ModeSetuid != 0 {
+		mode |= syscall.S_ISUID
+	}
+	if perm&os.ModeSetgid != 0 {
+		mode |= syscall.S_ISGID
+	}
+	if perm&os.ModeSticky != 0 {
+		mode |= syscall.S_ISVTX
+	}
+	return mode
+}
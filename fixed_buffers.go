@@ -4,6 +4,8 @@ package iouring
 
 import (
 	"errors"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	iouring_syscall "github.com/iceber/iouring-go/syscall"
@@ -23,3 +25,162 @@ func (iour *IOURing) RegisterBuffers(bs [][]byte) error {
 func (iour *IOURing) UnRegisterBuffers() error {
 	return iouring_syscall.IOURingRegister(iour.fd, iouring_syscall.IORING_UNREGISTER_BUFFERS, nil, 0)
 }
+
+// FixedBufferPool registers a slab of count buffers of size bytes,
+// and leases the registered buffers to ReadFixed and WriteFixed requests
+type FixedBufferPool struct {
+	iour *IOURing
+
+	size    int
+	buffers []byte
+
+	lock  sync.Mutex
+	frees []int
+}
+
+// NewFixedBufferPool registers the buffers of the pool,
+// the buffers of the IOURing can only be registered once
+func (iour *IOURing) NewFixedBufferPool(count int, size int) (*FixedBufferPool, error) {
+	if count <= 0 || size <= 0 {
+		return nil, errors.New("invalid buffer count or size")
+	}
+
+	pool := &FixedBufferPool{
+		iour:    iour,
+		size:    size,
+		buffers: make([]byte, count*size),
+		frees:   make([]int, 0, count),
+	}
+
+	bs := make([][]byte, count)
+	for i := range bs {
+		bs[i] = pool.buffer(i)
+	}
+	if err := iour.RegisterBuffers(bs); err != nil {
+		return nil, err
+	}
+
+	for i := count - 1; i >= 0; i-- {
+		pool.frees = append(pool.frees, i)
+	}
+	return pool, nil
+}
+
+func (pool *FixedBufferPool) BufferSize() int {
+	return pool.size
+}
+
+// Lease leases a free registered buffer, the lease must be released after use
+func (pool *FixedBufferPool) Lease() (*FixedBufferLease, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if len(pool.frees) == 0 {
+		return nil, ErrNoFixedBuffer
+	}
+
+	index := pool.frees[len(pool.frees)-1]
+	pool.frees = pool.frees[:len(pool.frees)-1]
+	return &FixedBufferLease{pool: pool, index: index, b: pool.buffer(index)}, nil
+}
+
+// Close unregisters the buffers of the pool
+func (pool *FixedBufferPool) Close() error {
+	return pool.iour.UnRegisterBuffers()
+}
+
+func (pool *FixedBufferPool) buffer(index int) []byte {
+	offset := index * pool.size
+	return pool.buffers[offset : offset+pool.size : offset+pool.size]
+}
+
+func (pool *FixedBufferPool) release(index int) {
+	pool.lock.Lock()
+	pool.frees = append(pool.frees, index)
+	pool.lock.Unlock()
+}
+
+// FixedBufferLease is a registered buffer leased from the FixedBufferPool
+type FixedBufferLease struct {
+	pool     *FixedBufferPool
+	index    int
+	b        []byte
+	released int32
+}
+
+// Index returns the index of the registered buffer
+func (lease *FixedBufferLease) Index() int {
+	return lease.index
+}
+
+func (lease *FixedBufferLease) Bytes() []byte {
+	return lease.b
+}
+
+// Release gives the buffer back to the pool
+func (lease *FixedBufferLease) Release() {
+	if atomic.CompareAndSwapInt32(&lease.released, 0, 1) {
+		lease.pool.release(lease.index)
+	}
+}
+
+// buffer returns the first n bytes of the leased buffer
+func (lease *FixedBufferLease) buffer(n int) ([]byte, error) {
+	if lease == nil || len(lease.b) == 0 {
+		return nil, errors.New("invalid fixed buffer lease")
+	}
+	if n < 0 || n > len(lease.b) {
+		return nil, errors.New("invalid fixed buffer length")
+	}
+	return lease.b[:n], nil
+}
+
+// ReadFixed reads into the first n bytes of the leased buffer, n must be in the range of the buffer,
+// the lease is released by Result.FreeRequestBuffer
+func ReadFixed(fd int, lease *FixedBufferLease, n int, offset uint64) (PrepRequest, error) {
+	b, err := lease.buffer(n)
+	if err != nil {
+		return nil, err
+	}
+	bp := unsafe.Pointer(&lease.b[0])
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+		userData.request.lease = lease
+		userData.SetRequestBuffer(b, nil)
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_READ_FIXED,
+			int32(fd),
+			uint64(uintptr(bp)),
+			uint32(len(b)),
+			offset,
+		)
+		sqe.SetBufIndex(uint16(lease.index))
+	}, nil
+}
+
+// WriteFixed writes the first n bytes of the leased buffer, n must be in the range of the buffer,
+// the lease is released by Result.FreeRequestBuffer
+func WriteFixed(fd int, lease *FixedBufferLease, n int, offset uint64) (PrepRequest, error) {
+	b, err := lease.buffer(n)
+	if err != nil {
+		return nil, err
+	}
+	bp := unsafe.Pointer(&lease.b[0])
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+		userData.request.lease = lease
+		userData.SetRequestBuffer(b, nil)
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_WRITE_FIXED,
+			int32(fd),
+			uint64(uintptr(bp)),
+			uint32(len(b)),
+			offset,
+		)
+		sqe.SetBufIndex(uint16(lease.index))
+	}, nil
+}
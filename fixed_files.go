@@ -15,10 +15,16 @@ func (iour *IOURing) GetFixedFileIndex(file *os.File) (int, bool) {
 	return iour.fileRegister.GetFileIndex(int32(file.Fd()))
 }
 
+// RegisterFile registers the file into a free slot of the registered file set, see RegisterFiles
 func (iour *IOURing) RegisterFile(file *os.File) error {
 	return iour.fileRegister.RegisterFile(int32(file.Fd()))
 }
 
+// RegisterFiles registers the files into the free slots of the registered file set.
+// If there are not enough free slots, the file set grows by being unregistered and registered again,
+// on the kernels which wait for the in-flight requests of the fixed files when the file set is
+// unregistered, it blocks until the requests are completed, like a pending recv of a fixed file.
+// After ReserveDirectFiles, the file set can't grow and an error is returned without free slots
 func (iour *IOURing) RegisterFiles(files []*os.File) error {
 	fds := make([]int32, 0, len(files))
 	for _, file := range files {
@@ -41,6 +47,20 @@ func (iour *IOURing) UnregisterFiles(files []*os.File) error {
 	return iour.fileRegister.UnregisterFiles(fds)
 }
 
+// ReserveDirectFiles reserves n slots at the end of the registered file set,
+// the kernel allocates the slots for the direct descriptors of requests like AcceptMultishotDirect,
+// and the slots are never used by the registered files.
+// The file set is registered again with free slots for the registered files, at least 16 and
+// no less than the registered files, the file set can't grow after the direct files are reserved
+// Available since 6.0
+func (iour *IOURing) ReserveDirectFiles(n int) error {
+	register, ok := iour.fileRegister.(*fileRegister)
+	if !ok {
+		return errors.New("direct files are not supported by the file register")
+	}
+	return register.reserveDirectFiles(n)
+}
+
 func (iour *IOURing) FileRegister() FileRegister {
 	return iour.fileRegister
 }
@@ -57,13 +77,23 @@ type fileRegister struct {
 	lock      sync.Mutex
 	iouringFd int
 
-	fds          []int32
-	sparseIndexs map[int]int
+	fds []int32
 
 	registered bool
 	indexs     sync.Map
+
+	// slots reserved for the direct descriptors allocated by the kernel,
+	// the registered files use the slots before directOffset
+	directOffset int
+	directs      int
 }
 
+// minFreeFiles is the minimum number of free slots kept for the registered files
+// when the direct files are reserved
+const minFreeFiles = 16
+
+var errNoFreeSlot = errors.New("no free slot in the registered file set")
+
 func (register *fileRegister) GetFileIndex(fd int32) (int, bool) {
 	if fd < 0 {
 		return -1, false
@@ -87,7 +117,9 @@ func (register *fileRegister) register() error {
 	}
 
 	for i, fd := range register.fds {
-		register.indexs.Store(fd, i)
+		if fd >= 0 {
+			register.indexs.Store(fd, i)
+		}
 	}
 	register.registered = true
 	return nil
@@ -97,6 +129,43 @@ func (register *fileRegister) unregister() error {
 	return iouring_syscall.IOURingRegister(register.iouringFd, iouring_syscall.IORING_UNREGISTER_FILES, nil, 0)
 }
 
+// reregister registers the fds as the new file set, the old file set is restored if it fails
+func (register *fileRegister) reregister(fds []int32) error {
+	if register.registered {
+		if err := register.unregister(); err != nil {
+			return err
+		}
+		register.registered = false
+	}
+
+	origin := register.fds
+	register.fds = fds
+	if err := register.register(); err != nil {
+		register.fds = origin
+		if len(origin) > 0 {
+			register.register()
+		}
+		return err
+	}
+	return nil
+}
+
+// freeSlots returns at most n free slots for the registered files
+func (register *fileRegister) freeSlots(n int) []int {
+	end := len(register.fds)
+	if register.directs > 0 {
+		end = register.directOffset
+	}
+
+	slots := make([]int, 0, n)
+	for i := 0; i < end && len(slots) < n; i++ {
+		if register.fds[i] == -1 {
+			slots = append(slots, i)
+		}
+	}
+	return slots
+}
+
 func (register *fileRegister) RegisterFiles(fds []int32) error {
 	if len(fds) == 0 {
 		return errors.New("file set is empty")
@@ -123,48 +192,39 @@ func (register *fileRegister) RegisterFiles(fds []int32) error {
 	defer register.lock.Unlock()
 
 	if !register.registered {
-		register.fds = fds
-		return register.register()
-	}
-
-	var fdi int
-	indexs := make(map[int32]int, len(fds))
-	updatedSpares := make(map[int]int, len(fds))
-	for i, spares := range register.sparseIndexs {
-		updatedSpares[i] = spares
-		for si := 0; si < spares; si++ {
-			for ; fdi < len(fds); fdi++ {
-				register.fds[i+si] = fds[fdi]
-				indexs[fds[fdi]] = i + si
-
-				fdi++
-				updatedSpares[i]--
-				break
-			}
-
-			if fdi >= len(fds) {
-				goto update
-			}
+		return register.reregister(fds)
+	}
+
+	slots := register.freeSlots(len(fds))
+	if len(slots) < len(fds) {
+		// the slots reserved for the direct files would be lost by registering the file set again
+		if register.directs > 0 {
+			return errNoFreeSlot
 		}
+
+		grown := make([]int32, 0, len(register.fds)+len(fds)-len(slots))
+		grown = append(grown, register.fds...)
+		for i, slot := range slots {
+			grown[slot] = fds[i]
+		}
+		return register.reregister(append(grown, fds[len(slots):]...))
 	}
-	register.fds = append(register.fds, fds[fdi:]...)
 
-update:
-	if err := register.fresh(0, len(register.fds)); err != nil {
-		return err
+	for i, slot := range slots {
+		register.fds[slot] = fds[i]
 	}
 
-	for i, spares := range updatedSpares {
-		if spares > 0 {
-			register.sparseIndexs[i] = spares
-			continue
+	first, last := slots[0], slots[len(slots)-1]
+	if err := register.fresh(first, last-first+1); err != nil {
+		for _, slot := range slots {
+			register.fds[slot] = -1
 		}
-		delete(register.sparseIndexs, i)
-	}
-	for i, fd := range register.fds {
-		register.indexs.Store(fd, i)
+		return err
 	}
 
+	for i, slot := range slots {
+		register.indexs.Store(fds[i], slot)
+	}
 	return nil
 }
 
@@ -172,38 +232,7 @@ func (register *fileRegister) RegisterFile(fd int32) error {
 	if fd < 0 {
 		return nil
 	}
-
-	if _, ok := register.GetFileIndex(fd); ok {
-		return nil
-	}
-
-	register.lock.Lock()
-	defer register.lock.Unlock()
-
-	if !register.registered {
-		register.fds = []int32{fd}
-		return register.register()
-	}
-
-	var fdi int
-	var spares int
-	for fdi, spares = range register.sparseIndexs {
-		break
-	}
-	register.fds[fdi] = fd
-
-	if err := register.fresh(fdi, 1); err != nil {
-		return err
-	}
-
-	if spares == 1 {
-		delete(register.sparseIndexs, fdi)
-	} else {
-		register.sparseIndexs[fdi]--
-	}
-
-	register.indexs.Store(fd, fdi)
-	return nil
+	return register.RegisterFiles([]int32{fd})
 }
 
 func (register *fileRegister) UnregisterFile(fd int32) error {
@@ -238,7 +267,7 @@ func (register *fileRegister) UnregisterFiles(fds []int32) error {
 		}
 		unregistered = true
 	}
-	if unregistered {
+	if !unregistered {
 		return nil
 	}
 
@@ -260,19 +289,6 @@ func (register *fileRegister) deleteFile(fd int32) (fdi int, ok bool) {
 
 	fdi = v.(int)
 	register.fds[fdi] = -1
-
-	var updated bool
-	for i, sparse := range register.sparseIndexs {
-		if i+sparse == fdi {
-			register.sparseIndexs[i]++
-			updated = true
-			break
-		}
-	}
-
-	if !updated {
-		register.sparseIndexs[fdi] = 1
-	}
 	return
 }
 
@@ -285,6 +301,79 @@ func (register *fileRegister) fresh(offset int, length int) error {
 		register.iouringFd,
 		iouring_syscall.IORING_REGISTER_FILES_UPDATE,
 		unsafe.Pointer(&update),
-		uint32(len(register.fds)),
+		uint32(length),
 	)
 }
+
+// isDirectSlot reports whether the index is one of the slots reserved for the direct files
+func (register *fileRegister) isDirectSlot(index int) bool {
+	register.lock.Lock()
+	defer register.lock.Unlock()
+
+	return register.directs > 0 && index >= register.directOffset && index < register.directOffset+register.directs
+}
+
+// releaseSlot forgets the registered file in the slot which is closed by the request
+func (register *fileRegister) releaseSlot(index int) {
+	register.lock.Lock()
+	defer register.lock.Unlock()
+
+	if index < 0 || index >= len(register.fds) {
+		return
+	}
+	if register.directs > 0 && index >= register.directOffset {
+		return
+	}
+
+	if fd := register.fds[index]; fd >= 0 {
+		register.indexs.Delete(fd)
+		register.fds[index] = -1
+	}
+}
+
+func (register *fileRegister) reserveDirectFiles(n int) error {
+	if n <= 0 {
+		return errors.New("invalid direct file count")
+	}
+
+	register.lock.Lock()
+	defer register.lock.Unlock()
+
+	if register.directs > 0 {
+		return errors.New("direct files have been reserved")
+	}
+
+	// the file set can't grow after the direct files are reserved,
+	// so free slots are kept for the registered files
+	free := len(register.fds)
+	if free < minFreeFiles {
+		free = minFreeFiles
+	}
+
+	offset := len(register.fds) + free
+	fds := make([]int32, offset+n)
+	copy(fds, register.fds)
+	for i := len(register.fds); i < len(fds); i++ {
+		fds[i] = -1
+	}
+
+	if err := register.reregister(fds); err != nil {
+		return err
+	}
+
+	fileRange := iouring_syscall.IOURingFileIndexRange{Off: uint32(offset), Len: uint32(n)}
+	if err := iouring_syscall.IOURingRegister(
+		register.iouringFd,
+		iouring_syscall.IORING_REGISTER_FILE_ALLOC_RANGE,
+		unsafe.Pointer(&fileRange), 0,
+	); err != nil {
+		return err
+	}
+
+	// the reserved slots are owned by the kernel, and skipped by the file set updates
+	for i := offset; i < len(fds); i++ {
+		register.fds[i] = iouring_syscall.IORING_REGISTER_FILES_SKIP
+	}
+	register.directOffset, register.directs = offset, n
+	return nil
+}
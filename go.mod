@@ -1,5 +1,5 @@
 module github.com/iceber/iouring-go
 
-go 1.15
+go 1.16
 
 require golang.org/x/sys v0.0.0-20200923182605-d9f96fdee20d
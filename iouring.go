@@ -9,7 +9,12 @@ import (
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
 
 	iouring_syscall "github.com/iceber/iouring-go/syscall"
 )
@@ -20,23 +25,40 @@ type IOURing struct {
 	params *iouring_syscall.IOURingParams
 	fd     int
 
-	eventfd int
-	cqeSign chan struct{}
+	// eventfd is notified of completion events, and is waited by the netpoller
+	eventfd     *os.File
+	eventfdConn syscall.RawConn
 
 	sq *SubmissionQueue
 	cq *CompletionQueue
 
 	async    bool
 	drain    bool
+	disabled bool
+	raw      bool
 	Flags    uint32
 	Features uint32
 
 	submitLock sync.Mutex
 
+	cqLock     sync.Mutex
+	cqUnmapped bool
+
+	// waitArg is used by SubmitAndWait under the cqLock, it's in the ring on the heap,
+	// so the address of the timespec is not changed by the stack growth
+	waitArg geteventsArg
+
+	// completions of the reaped events are queued under the cqLock,
+	// and sent to the channels by the background goroutine without the cqLock
+	completions []completion
+
 	userDataLock sync.RWMutex
 	userDatas    map[uint64]*UserData
 
 	fileRegister FileRegister
+	probe        *Probe
+
+	messages chan<- Message
 
 	fdclosed bool
 	closer   chan struct{}
@@ -48,7 +70,6 @@ func New(entries uint, opts ...IOURingOption) (*IOURing, error) {
 	iour := &IOURing{
 		params:    &iouring_syscall.IOURingParams{},
 		userDatas: make(map[uint64]*UserData),
-		cqeSign:   make(chan struct{}, 1),
 		closer:    make(chan struct{}),
 		closed:    make(chan struct{}),
 	}
@@ -68,19 +89,22 @@ func New(entries uint, opts ...IOURingOption) (*IOURing, error) {
 		return nil, err
 	}
 
-	iour.fileRegister = &fileRegister{
-		iouringFd:    iour.fd,
-		sparseIndexs: make(map[int]int),
-	}
+	iour.fileRegister = &fileRegister{iouringFd: iour.fd}
 	iour.Flags = iour.params.Flags
 	iour.Features = iour.params.Features
+	iour.disabled = iour.Flags&iouring_syscall.IORING_SETUP_R_DISABLED != 0
 
-	if err := iour.registerEventfd(); err != nil {
-		iour.Close()
-		return nil, err
+	// probe is unavailable before 5.6, then opcodes are not checked before submitting
+	iour.probe, _ = iour.Probe()
+
+	// completion events are reaped by the user in raw mode
+	if iour.raw {
+		close(iour.closed)
+		return iour, nil
 	}
 
-	if err := registerIOURing(iour); err != nil {
+	if err := iour.registerEventfd(); err != nil {
+		close(iour.closed)
 		iour.Close()
 		return nil, err
 	}
@@ -105,19 +129,21 @@ func (iour *IOURing) Close() error {
 		close(iour.closer)
 	}
 
-	if iour.eventfd > 0 {
-		if err := removeIOURing(iour); err != nil {
-			return err
-		}
-		syscall.Close(iour.eventfd)
-		iour.eventfd = -1
+	// closing eventfd wakes up the background goroutine waiting for completion events
+	if iour.eventfd != nil {
+		iour.eventfd.Close()
+		iour.eventfd = nil
 	}
 
 	<-iour.closed
 
+	// completion queue may be reaped by SubmitAndWait
+	iour.cqLock.Lock()
+	defer iour.cqLock.Unlock()
 	if err := munmapIOURing(iour); err != nil {
 		return err
 	}
+	iour.cqUnmapped = true
 
 	if !iour.fdclosed {
 		if err := syscall.Close(iour.fd); err != nil {
@@ -155,10 +181,15 @@ func (iour *IOURing) doRequest(sqe iouring_syscall.SubmissionQueueEntry, request
 	request(sqe, userData)
 	userData.setOpcode(sqe.Opcode())
 
+	if !iour.IsSupported(sqe.Opcode()) {
+		return nil, &OpNotSupportedError{Opcode: sqe.Opcode()}
+	}
+
 	sqe.SetUserData(userData.id)
 
 	userData.request.fd = int(sqe.Fd())
-	if sqe.Fd() >= 0 {
+	userData.request.offset = sqe.Offset()
+	if sqe.Fd() >= 0 && !userData.rawFd {
 		if index, ok := iour.fileRegister.GetFileIndex(int32(sqe.Fd())); ok {
 			sqe.SetFdIndex(int32(index))
 		} else if iour.Flags&iouring_syscall.IORING_SETUP_SQPOLL != 0 &&
@@ -182,17 +213,35 @@ func (iour *IOURing) doRequest(sqe iouring_syscall.SubmissionQueueEntry, request
 	if iour.drain {
 		sqe.SetFlags(iouring_syscall.IOSQE_FLAGS_IO_DRAIN)
 	}
+	if userData.request.cont != nil {
+		userData.request.cont.sqe = iour.sq.sqes.copyEntry(sqe)
+	}
 	return userData, nil
 }
 
+// checkSubmit returns the error if the requests can not be submitted to the ring,
+// the caller must hold the submitLock
+func (iour *IOURing) checkSubmit() error {
+	if iour.IsClosed() {
+		return ErrIOURingClosed
+	}
+	if iour.disabled {
+		return ErrIOURingDisabled
+	}
+	if iour.raw {
+		return ErrRawMode
+	}
+	return nil
+}
+
 // SubmitRequest by Request function and io result is notified via channel
 // return request id, can be used to cancel a request
 func (iour *IOURing) SubmitRequest(request PrepRequest, ch chan<- Result) (Request, error) {
 	iour.submitLock.Lock()
 	defer iour.submitLock.Unlock()
 
-	if iour.IsClosed() {
-		return nil, ErrIOURingClosed
+	if err := iour.checkSubmit(); err != nil {
+		return nil, err
 	}
 
 	sqe := iour.getSQEntry()
@@ -218,16 +267,15 @@ func (iour *IOURing) SubmitRequest(request PrepRequest, ch chan<- Result) (Reque
 
 // SubmitRequests by Request functions and io results are notified via channel
 func (iour *IOURing) SubmitRequests(requests []PrepRequest, ch chan<- Result) (RequestSet, error) {
-	// TODO(iceber): no length limit
 	if len(requests) > int(*iour.sq.entries) {
-		return nil, errors.New("too many requests")
+		return iour.submitRequestsInStages(requests, ch)
 	}
 
 	iour.submitLock.Lock()
 	defer iour.submitLock.Unlock()
 
-	if iour.IsClosed() {
-		return nil, ErrIOURingClosed
+	if err := iour.checkSubmit(); err != nil {
+		return nil, err
 	}
 
 	var sqeN uint32
@@ -268,6 +316,124 @@ func (iour *IOURing) SubmitRequests(requests []PrepRequest, ch chan<- Result) (R
 	return rset, nil
 }
 
+// submitRequestsInStages submits the requests which are more than the entries of the submission queue,
+// they are submitted in stages, and a link chain in the requests is not split into two stages.
+// If a stage fails to be submitted after the previous stages, the rest requests are canceled
+func (iour *IOURing) submitRequestsInStages(requests []PrepRequest, ch chan<- Result) (RequestSet, error) {
+	iour.submitLock.Lock()
+	defer iour.submitLock.Unlock()
+
+	if err := iour.checkSubmit(); err != nil {
+		return nil, err
+	}
+
+	sqes, userDatas, err := iour.prepareRequests(requests, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	linkFlags := iouring_syscall.IOSQE_FLAGS_IO_LINK | iouring_syscall.IOSQE_FLAGS_IO_HARDLINK
+	entries := int(*iour.sq.entries)
+
+	var ends []int
+	for start := 0; start < len(sqes); {
+		end := start + entries
+		if end >= len(sqes) {
+			end = len(sqes)
+		} else {
+			for end > start && sqes[end-1].Flags()&linkFlags != 0 {
+				end--
+			}
+			if end == start {
+				return nil, ErrLinkChainTooLong
+			}
+		}
+		ends = append(ends, end)
+		start = end
+	}
+
+	rset := newRequestSet(userDatas)
+
+	iour.userDataLock.Lock()
+	for _, data := range userDatas {
+		iour.userDatas[data.id] = data
+	}
+	iour.userDataLock.Unlock()
+
+	var start int
+	for i, end := range ends {
+		if err := iour.submitStage(sqes[start:end]); err != nil {
+			if i == 0 {
+				iour.userDataLock.Lock()
+				for _, data := range userDatas {
+					delete(iour.userDatas, data.id)
+				}
+				iour.userDataLock.Unlock()
+				return nil, err
+			}
+
+			// requests of the previous stages are in flight
+			go iour.abortRequests(userDatas[start:])
+			break
+		}
+		start = end
+	}
+	return rset, nil
+}
+
+// prepareRequests prepares the requests in the entries made out of the submission queue,
+// the entries are copied into the queue by submitStage
+func (iour *IOURing) prepareRequests(requests []PrepRequest, ch chan<- Result) ([]iouring_syscall.SubmissionQueueEntry, []*UserData, error) {
+	sqes := make([]iouring_syscall.SubmissionQueueEntry, 0, len(requests))
+	userDatas := make([]*UserData, 0, len(requests))
+	for _, request := range requests {
+		sqe := iour.sq.sqes.makeEntry()
+		userData, err := iour.doRequest(sqe, request, ch)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sqes = append(sqes, sqe)
+		userDatas = append(userDatas, userData)
+	}
+	return sqes, userDatas, nil
+}
+
+// submitStage copies the entries into the submission queue and submits them,
+// the caller must hold the submitLock
+func (iour *IOURing) submitStage(sqes []iouring_syscall.SubmissionQueueEntry) error {
+	// the entries left in the queue are submitted first, then the stage is put into the queue at once,
+	// the kernel thread of SQPOLL consumes the entries by itself
+	for int(iour.sq.free()) < len(sqes) {
+		if _, err := iour.submit(); err != nil {
+			return err
+		}
+		runtime.Gosched()
+	}
+
+	for _, sqe := range sqes {
+		iour.sq.putSQEntry(sqe)
+	}
+	_, err := iour.submit()
+	return err
+}
+
+// abortRequests completes the requests which are not submitted to the kernel
+func (iour *IOURing) abortRequests(userDatas []*UserData) {
+	iour.userDataLock.Lock()
+	for _, data := range userDatas {
+		delete(iour.userDatas, data.id)
+	}
+	iour.userDataLock.Unlock()
+
+	for _, data := range userDatas {
+		data.request.abort()
+		if data.resulter != nil {
+			data.resulter <- data.request
+		}
+	}
+}
+
 func (iour *IOURing) needEnter(flags *uint32) bool {
 	if (iour.Flags & iouring_syscall.IORING_SETUP_SQPOLL) == 0 {
 		return true
@@ -293,29 +459,105 @@ func (iour *IOURing) submit() (submitted int, err error) {
 	}
 
 	submitted, err = iouring_syscall.IOURingEnter(iour.fd, uint32(submitted), 0, flags, nil)
+	if err != nil && iour.Flags&iouring_syscall.IORING_SETUP_SQPOLL == 0 && !iour.raw {
+		// the kernel consumes nothing if io_uring_enter fails, the entries are dropped,
+		// otherwise they are submitted by the next submission after the requests are given up.
+		// the entries can't be dropped safely when the kernel thread polls the queue,
+		// and the entries of raw mode are owned by the user
+		iour.sq.rollback()
+	}
 	return
 }
 
-/*
-func (iour *IOURing) submitAndWait(waitCount uint32) (submitted int, err error) {
-	submitted = iour.sq.flush()
+// SubmitAndWait submits the requests and waits until at least minComplete requests are completed,
+// the completion events are reaped by the calling goroutine without the background goroutine,
+// and the results of other requests are still sent to their channels by the background goroutine.
+// If the timeout expires, the RequestSet is returned with ErrWaitTimeout,
+// and the uncompleted requests are still in flight. Zero timeout waits without limit
+// Available since 5.11
+func (iour *IOURing) SubmitAndWait(requests []PrepRequest, minComplete int, timeout time.Duration) (RequestSet, error) {
+	// every wait is bounded, so Close is not blocked by the waiter
+	if iour.Features&iouring_syscall.IORING_FEAT_EXT_ARG == 0 {
+		return nil, ErrFeatureNotSupported
+	}
+	if minComplete > len(requests) {
+		minComplete = len(requests)
+	}
 
-	var flags uint32
-	if !iour.needEnter(&flags) && waitCount == 0 {
-		return
+	set, err := iour.SubmitRequests(requests, nil)
+	if err != nil {
+		return nil, err
 	}
+	rset := set.(*requestSet)
 
-	if waitCount != 0 || (iour.Flags&iouring_syscall.IORING_SETUP_IOPOLL) != 0 {
-		flags |= iouring_syscall.IORING_ENTER_FLAGS_GETEVENTS
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
 	}
 
-	submitted, err = iouring_syscall.IOURingEnter(iour.fd, uint32(submitted), waitCount, flags, nil)
-	return
+	for {
+		complated, err := iour.waitRequestSet(rset, minComplete, deadline)
+		if err != nil {
+			return set, err
+		}
+		if complated {
+			return set, nil
+		}
+	}
 }
-*/
 
-func (iour *IOURing) getCQEvent(wait bool) (cqe iouring_syscall.CompletionQueueEvent, err error) {
-	var tryPeeks int
+// maxGeteventsWait bounds every wait of SubmitAndWait, so Close is not blocked by the waiter
+const maxGeteventsWait = 100 * time.Millisecond
+
+// geteventsArg keeps the timespec referenced by the arg
+type geteventsArg struct {
+	arg iouring_syscall.IOURingGeteventsArg
+	ts  unix.Timespec
+}
+
+// waitRequestSet reaps the completion events, and waits for one more event if the set has not
+// completed minComplete requests. cqLock is held across checking and waiting, then the events
+// of the set can not be reaped by the background goroutine before the wait
+func (iour *IOURing) waitRequestSet(set *requestSet, minComplete int, deadline time.Time) (bool, error) {
+	iour.cqLock.Lock()
+	defer iour.cqLock.Unlock()
+
+	if err := iour.reapCQEventsLocked(); err != nil {
+		return false, err
+	}
+	if len(iour.completions) > 0 {
+		// the completions of other requests are sent by the background goroutine,
+		// the caller may be the receiver of the channels
+		iour.notifyEventfd()
+	}
+	if int(atomic.LoadInt32(&set.complates)) >= minComplete {
+		return true, nil
+	}
+
+	wait := maxGeteventsWait
+	if !deadline.IsZero() {
+		remain := time.Until(deadline)
+		if remain <= 0 {
+			return false, ErrWaitTimeout
+		}
+		if remain < wait {
+			wait = remain
+		}
+	}
+
+	arg := &iour.waitArg
+	arg.ts = unix.NsecToTimespec(wait.Nanoseconds())
+	arg.arg = iouring_syscall.IOURingGeteventsArg{Ts: uint64(uintptr(unsafe.Pointer(&arg.ts)))}
+	_, err := iouring_syscall.IOURingEnterWithArg(iour.fd, 0, 1, iouring_syscall.IORING_ENTER_FLAGS_GETEVENTS, &arg.arg)
+
+	// completion events are reaped and the deadline is checked again
+	if err != nil && !errors.Is(err, syscall.ETIME) && !errors.Is(err, syscall.EINTR) {
+		return false, err
+	}
+	return false, nil
+}
+
+func (iour *IOURing) getCQEvent() (cqe iouring_syscall.CompletionQueueEvent, err error) {
 	for {
 		if cqe = iour.cq.peek(); cqe != nil {
 			// Copy CQE.
@@ -324,17 +566,23 @@ func (iour *IOURing) getCQEvent(wait bool) (cqe iouring_syscall.CompletionQueueE
 			return
 		}
 
-		if !wait && !iour.sq.cqOverflow() {
-			err = syscall.EAGAIN
+		if !iour.sq.cqOverflow() {
+			return nil, syscall.EAGAIN
+		}
+
+		_, err = iouring_syscall.IOURingEnter(iour.fd, 0, 0, iouring_syscall.IORING_ENTER_FLAGS_GETEVENTS, nil)
+		if err != nil {
 			return
 		}
+	}
+}
 
-		if iour.sq.cqOverflow() {
-			_, err = iouring_syscall.IOURingEnter(iour.fd, 0, 0, iouring_syscall.IORING_ENTER_FLAGS_GETEVENTS, nil)
-			if err != nil {
-				return
-			}
-			continue
+// waitCQEvent waits until there are completion events in the completion queue
+func (iour *IOURing) waitCQEvent() error {
+	var tryPeeks int
+	for {
+		if iour.cq.peek() != nil || iour.sq.cqOverflow() {
+			return nil
 		}
 
 		if tryPeeks++; tryPeeks < 3 {
@@ -342,48 +590,156 @@ func (iour *IOURing) getCQEvent(wait bool) (cqe iouring_syscall.CompletionQueueE
 			continue
 		}
 
-		select {
-		case <-iour.cqeSign:
-		case <-iour.closer:
-			return nil, ErrIOURingClosed
+		if err := iour.waitEventfd(); err != nil {
+			return ErrIOURingClosed
 		}
+
+		// the eventfd is also notified for the completions queued by SubmitAndWait
+		return nil
 	}
 }
 
-func (iour *IOURing) run() {
+// reapCQEvents handles all completion events in the completion queue, and returns the queued
+// completions, the buf is used as the next queue. Completion events are reaped by the background
+// goroutine and SubmitAndWait, so cqLock makes sure that every completion event is handled once and in order
+func (iour *IOURing) reapCQEvents(buf []completion) ([]completion, error) {
+	iour.cqLock.Lock()
+	defer iour.cqLock.Unlock()
+
+	err := iour.reapCQEventsLocked()
+	completions := iour.completions
+	iour.completions = buf[:0]
+	return completions, err
+}
+
+// reapCQEventsLocked is like reapCQEvents, but the caller must hold the cqLock
+func (iour *IOURing) reapCQEventsLocked() error {
+	if iour.cqUnmapped {
+		return ErrIOURingClosed
+	}
+
 	for {
-		cqe, err := iour.getCQEvent(true)
-		if cqe == nil || err != nil {
-			if err == ErrIOURingClosed {
-				close(iour.closed)
-				return
+		cqe, err := iour.getCQEvent()
+		if err != nil {
+			if err == syscall.EAGAIN {
+				return nil
 			}
-			log.Println("runComplete error: ", err)
-			continue
+			return err
 		}
+		iour.handleCQE(cqe)
+	}
+}
 
-		// log.Println("cqe user data", (cqe.UserData))
+func (iour *IOURing) run() {
+	var completions []completion
+	for {
+		err := iour.waitCQEvent()
 
-		iour.userDataLock.Lock()
-		userData := iour.userDatas[cqe.UserData()]
-		if userData == nil {
+		var rerr error
+		completions, rerr = iour.reapCQEvents(completions)
+		if rerr != nil && err == nil {
+			log.Println("runComplete error: ", rerr)
+		}
+
+		// the channels are sent without the cqLock, so the receiver can call SubmitAndWait
+		for i := range completions {
+			iour.send(completions[i])
+			completions[i] = completion{}
+		}
+
+		if err != nil {
+			close(iour.closed)
+			return
+		}
+	}
+}
+
+// completion is the result or the message sent to the channel by the background goroutine
+type completion struct {
+	resulter chan<- Result
+	result   Result
+	message  Message
+}
+
+// deliver queues the completion, the caller must hold the cqLock
+func (iour *IOURing) deliver(c completion) {
+	iour.completions = append(iour.completions, c)
+}
+
+func (iour *IOURing) send(c completion) {
+	if c.resulter == nil {
+		iour.messages <- c.message
+		return
+	}
+	c.resulter <- c.result
+}
+
+func (iour *IOURing) handleCQE(cqe iouring_syscall.CompletionQueueEvent) {
+	// log.Println("cqe user data", (cqe.UserData))
+
+	iour.userDataLock.Lock()
+	userData := iour.userDatas[cqe.UserData()]
+	if userData == nil {
+		iour.userDataLock.Unlock()
+
+		// completion event is posted by other ring
+		if iour.messages != nil {
+			iour.deliver(completion{message: Message{UserData: cqe.UserData(), Result: cqe.Result(), Flags: cqe.Flags()}})
+			return
+		}
+		log.Println("runComplete: notfound user data ", uintptr(cqe.UserData()))
+		return
+	}
+	more := cqe.Flags()&iouring_syscall.IORING_CQE_F_MORE != 0
+	if !more && userData.request.cont != nil {
+		// the request is still in flight for the remaining buffer, it can be canceled by the id
+		if sqe := userData.request.cont.next(userData.request, cqe.Result()); sqe != nil {
 			iour.userDataLock.Unlock()
-			log.Println("runComplete: notfound user data ", uintptr(cqe.UserData()))
-			continue
+			go iour.resubmit(userData, sqe)
+			return
 		}
+	}
+	if !more {
 		delete(iour.userDatas, cqe.UserData())
-		iour.userDataLock.Unlock()
+	}
+	iour.userDataLock.Unlock()
 
-		userData.request.complate(cqe)
+	// zero-copy send request is completed by the first completion event,
+	// and the notification event tells that the buffer can be reused
+	if userData.request.released != nil {
+		if cqe.Flags()&iouring_syscall.IORING_CQE_F_NOTIF != 0 {
+			close(userData.request.released)
+			return
+		}
 
-		// ignore link timeout
-		if userData.opcode == iouring_syscall.IORING_OP_LINK_TIMEOUT {
-			continue
+		userData.request.complate(cqe)
+		if !more {
+			close(userData.request.released)
 		}
+		if userData.resulter != nil {
+			iour.deliver(completion{resulter: userData.resulter, result: userData.request})
+		}
+		return
+	}
 
+	// multishot request posts a result for every completion event,
+	// and the request is completed by the last one
+	if more {
 		if userData.resulter != nil {
-			userData.resulter <- userData.request
+			iour.deliver(completion{resulter: userData.resulter, result: userData.request.fork(cqe)})
 		}
+		return
+	}
+
+	userData.request.complate(cqe)
+
+	// ignore link timeout
+	if userData.opcode == iouring_syscall.IORING_OP_LINK_TIMEOUT {
+		return
+	}
+
+	if userData.resulter != nil {
+		iour.deliver(completion{resulter: userData.resulter, result: userData.request})
 	}
 }
 
@@ -1,9 +1,19 @@
 package iouring
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"syscall"
 	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
 )
 
 func testSubmitRequests(t *testing.T, nreqs uint) {
@@ -47,3 +57,1548 @@ func TestSubmitRequests(t *testing.T) {
 		t.Run(fmt.Sprintf("%d", nreqs), func(t *testing.T) { testSubmitRequests(t, nreqs) })
 	}
 }
+
+func TestSplice(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	file, err := os.Create(t.TempDir() + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	ch := make(chan Result, 1)
+	splice := func(offset int64, data string) {
+		t.Helper()
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := iour.SubmitRequest(Splice(int(r.Fd()), -1, int(file.Fd()), offset, uint32(len(data)), 0), ch); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := (<-ch).ReturnInt(); err != nil || n != len(data) {
+			t.Fatalf("unexpected splice: %d, %v", n, err)
+		}
+	}
+
+	splice(0, "io_")
+
+	// the registered pipe is used by its index
+	if err := iour.RegisterFile(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := iour.GetFixedFileIndex(r); !ok {
+		t.Fatal("pipe is not registered")
+	}
+	splice(3, "uring")
+
+	b := make([]byte, 16)
+	if n, err := file.ReadAt(b, 0); string(b[:n]) != "io_uring" {
+		t.Fatalf("unexpected file data: %q, %v", b[:n], err)
+	}
+}
+
+func TestTee(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	r1, w1, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r1.Close()
+	defer w1.Close()
+
+	r2, w2, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r2.Close()
+	defer w2.Close()
+
+	ch := make(chan Result, 1)
+	b := make([]byte, 16)
+	for i, data := range []string{"io_uring", "tee"} {
+		// the registered pipe is used by its index
+		if i == 1 {
+			if err := iour.RegisterFile(r1); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := w1.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := iour.SubmitRequest(Tee(int(r1.Fd()), int(w2.Fd()), uint32(len(data)), 0), ch); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := (<-ch).ReturnInt(); err != nil || n != len(data) {
+			t.Fatalf("unexpected tee: %d, %v", n, err)
+		}
+
+		// the data is duplicated without being consumed
+		for _, r := range []*os.File{r1, r2} {
+			if n, err := r.Read(b); err != nil || string(b[:n]) != data {
+				t.Fatalf("unexpected pipe data: %q, %v", b[:n], err)
+			}
+		}
+	}
+}
+
+func TestBufferGroup(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	if _, err := iour.ProvideBufferGroup(1, 1<<16+1, 1); err == nil {
+		t.Fatal("buffer count out of the buffer id space is accepted")
+	}
+
+	group, err := iour.ProvideBufferGroup(1, 1, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer group.Remove()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	// the only buffer of the group is selected again after it is released
+	ch := make(chan Result, 1)
+	for i, data := range []string{"first", "second", "third"} {
+		var prep PrepRequest
+		if i%2 == 0 {
+			prep = ReadWithBufferGroup(int(r.Fd()), group)
+			_, err = w.Write([]byte(data))
+		} else {
+			prep = RecvWithBufferGroup(fds[0], group, 0)
+			_, err = unix.Write(fds[1], []byte(data))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := iour.SubmitRequest(prep, ch); err != nil {
+			t.Fatal(err)
+		}
+		result := <-ch
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if bid, ok := result.BufferID(); !ok || bid != 0 {
+			t.Fatalf("unexpected buffer id: %d, %v", bid, ok)
+		}
+		if b, _ := result.GetRequestBuffer(); string(b) != data {
+			t.Fatalf("unexpected data: %q", b)
+		}
+		result.FreeRequestBuffer()
+	}
+}
+
+func TestBufferRingIncremental(t *testing.T) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	ring, err := iour.RegisterBufferRing(1, 2, 64, iouring_syscall.IOU_PBUF_RING_INC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Unregister()
+	if !ring.Incremental() {
+		t.Skip("incremental buffer ring is not supported")
+	}
+
+	// the small messages are received into the same buffer at the advancing offsets
+	ch := make(chan Result, 1)
+	var bid uint16
+	var offset int
+	for i, data := range []string{"first", "second", "third"} {
+		if _, err := unix.Write(fds[1], []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := iour.SubmitRequest(RecvWithBufferGroup(fds[0], ring, 0), ch); err != nil {
+			t.Fatal(err)
+		}
+
+		result := <-ch
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		id, ok := result.BufferID()
+		if !ok {
+			t.Fatal("no buffer is selected")
+		}
+		if i == 0 {
+			bid = id
+		} else if id != bid {
+			t.Fatalf("unexpected buffer id: %d, expected %d", id, bid)
+		}
+
+		b, _ := result.GetRequestBuffer()
+		if string(b) != data {
+			t.Fatalf("unexpected data: %q", b)
+		}
+		if &b[0] != &ring.Buffer(bid)[offset] {
+			t.Fatalf("data of message %d is not at offset %d of the buffer", i, offset)
+		}
+		offset += len(data)
+		result.FreeRequestBuffer()
+	}
+}
+
+func TestFixedBufferPool(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	pool, err := iour.NewFixedBufferPool(2, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	file, err := os.Create(t.TempDir() + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	write, err := pool.Lease()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := pool.Lease()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Lease(); err != ErrNoFixedBuffer {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := WriteFixed(int(file.Fd()), write, len(write.Bytes())+1, 0); err == nil {
+		t.Fatal("length out of the buffer is accepted")
+	}
+	if _, err := ReadFixed(int(file.Fd()), read, -1, 0); err == nil {
+		t.Fatal("negative length is accepted")
+	}
+
+	n := copy(write.Bytes(), "io_uring")
+	prep, err := WriteFixed(int(file.Fd()), write, n, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := iour.SubmitRequest(prep, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-request.Done()
+	if written, err := request.ReturnInt(); err != nil || written != n {
+		t.Fatalf("unexpected write: %d, %v", written, err)
+	}
+
+	// the lease is given back to the pool by FreeRequestBuffer only once
+	request.FreeRequestBuffer()
+	request.FreeRequestBuffer()
+	lease, err := pool.Lease()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Index() != write.Index() {
+		t.Fatalf("unexpected leased buffer: %d", lease.Index())
+	}
+	if _, err := pool.Lease(); err != ErrNoFixedBuffer {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lease.Release()
+
+	prep, err = ReadFixed(int(file.Fd()), read, n, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err = iour.SubmitRequest(prep, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-request.Done()
+	if b, _ := request.GetRequestBuffer(); string(b) != "io_uring" {
+		t.Fatalf("unexpected read: %q", b)
+	}
+	request.FreeRequestBuffer()
+}
+
+func TestRestrictions(t *testing.T) {
+	iour, err := New(4, WithDisableRing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	if _, err := iour.SubmitRequest(Nop(), nil); !errors.Is(err, ErrIOURingDisabled) {
+		t.Fatalf("unexpected error of the disabled ring: %v", err)
+	}
+
+	if err := iour.RegisterRestrictions(NewRestrictions().AllowOps(iouring_syscall.IORING_OP_NOP)); err != nil {
+		if errors.Is(err, unix.EINVAL) {
+			t.Skip("restrictions are not supported")
+		}
+		t.Fatal(err)
+	}
+	if err := iour.Enable(); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Result, 1)
+	if _, err := iour.SubmitRequest(Nop(), ch); err != nil {
+		t.Fatal(err)
+	}
+	if err := (<-ch).Err(); err != nil {
+		t.Fatalf("unexpected error of the allowed opcode: %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if _, err := iour.SubmitRequest(Write(int(w.Fd()), []byte("x")), ch); err != nil {
+		t.Fatal(err)
+	}
+	if err := (<-ch).Err(); !errors.Is(err, unix.EACCES) {
+		t.Fatalf("unexpected error of the disallowed opcode: %v", err)
+	}
+}
+
+func TestPersonality(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	id, err := iour.RegisterPersonality()
+	if err != nil {
+		if errors.Is(err, unix.EINVAL) {
+			t.Skip("personality is not supported")
+		}
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	ch := make(chan Result, 1)
+	if _, err := iour.SubmitRequest(Write(int(w.Fd()), []byte("x")).WithPersonality(id), ch); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := (<-ch).ReturnInt(); err != nil || n != 1 {
+		t.Fatalf("unexpected write: %d, %v", n, err)
+	}
+
+	if err := iour.UnregisterPersonality(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := iour.UnregisterPersonality(id); err == nil {
+		t.Fatal("unknown personality is unregistered")
+	}
+
+	// the request with the unregistered personality is rejected by the kernel
+	if _, err := iour.SubmitRequest(Write(int(w.Fd()), []byte("x")).WithPersonality(id), ch); err != nil {
+		t.Fatal(err)
+	}
+	if err := (<-ch).Err(); !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSendZC(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	if !iour.IsSupported(OpSendZC) || !iour.IsSupported(OpSendmsgZC) {
+		t.Skip("zero-copy send is not supported")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	cfile, err := client.(*net.TCPConn).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfile.Close()
+
+	server, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	sendmsg, err := SendmsgZC(int(cfile.Fd()), []byte("uring"), nil, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Result, 2)
+	for _, prep := range []PrepRequest{SendZC(int(cfile.Fd()), []byte("io_"), 0), sendmsg} {
+		request, err := iour.SubmitRequest(prep, ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-request.Done()
+		if err := request.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the buffer is released by the notification event after the request is done
+		select {
+		case <-request.BufferReleased():
+		case <-time.After(time.Second):
+			t.Fatal("buffer is not released")
+		}
+		if result := <-ch; result != request {
+			t.Fatal("unexpected result")
+		}
+	}
+
+	b := make([]byte, 8)
+	if _, err := io.ReadFull(server, b); err != nil || string(b) != "io_uring" {
+		t.Fatalf("unexpected data: %q, %v", b, err)
+	}
+
+	iour.userDataLock.RLock()
+	inflight := len(iour.userDatas)
+	iour.userDataLock.RUnlock()
+	if inflight != 0 {
+		t.Fatalf("user data of the released requests are kept: %d", inflight)
+	}
+
+	// the failed request has no notification event
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	request, err := iour.SubmitRequest(SendZC(int(w.Fd()), []byte("x"), 0), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	if err := request.Err(); err == nil {
+		t.Fatal("zero-copy send to the pipe succeeds")
+	}
+	select {
+	case <-request.BufferReleased():
+	case <-time.After(time.Second):
+		t.Fatal("buffer of the failed request is not released")
+	}
+}
+
+func TestSocketBindListen(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	for _, op := range []uint8{iouring_syscall.IORING_OP_SOCKET, iouring_syscall.IORING_OP_BIND, iouring_syscall.IORING_OP_LISTEN} {
+		if !iour.IsSupported(op) {
+			t.Skipf("opcode %d is not supported", op)
+		}
+	}
+
+	ch := make(chan Result, 1)
+	if _, err := iour.SubmitRequest(Socket(unix.AF_INET, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0), ch); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := (<-ch).ReturnFd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fd)
+
+	bind, err := Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := iour.SubmitRequest(bind, ch); err != nil {
+		t.Fatal(err)
+	}
+	if err := (<-ch).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := iour.SubmitRequest(Listen(fd, 1), ch); err != nil {
+		t.Fatal(err)
+	}
+	if err := (<-ch).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	sa, err := unix.Getsockname(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := sa.(*unix.SockaddrInet4)
+	if addr.Port == 0 {
+		t.Fatal("socket is not bound")
+	}
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", addr.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	nfd, _, err := unix.Accept(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unix.Close(nfd)
+}
+
+func TestXattr(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	if !iour.IsSupported(iouring_syscall.IORING_OP_SETXATTR) || !iour.IsSupported(iouring_syscall.IORING_OP_FGETXATTR) {
+		t.Skip("xattr requests are not supported")
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "xattr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ch := make(chan Result, 1)
+	submit := func(prepReq PrepRequest, err error) Result {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := iour.SubmitRequest(prepReq, ch); err != nil {
+			t.Fatal(err)
+		}
+		return <-ch
+	}
+
+	err = submit(Setxattr(f.Name(), "user.path", []byte("path value"), 0)).Err()
+	if errors.Is(err, unix.ENOTSUP) {
+		t.Skip("xattrs are not supported by the file system")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := submit(Fsetxattr(int(f.Fd()), "user.fd", []byte("fd value"), 0)).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// an empty dest returns the size of the value
+	if n, err := submit(Getxattr(f.Name(), "user.fd", nil)).ReturnInt(); n != len("fd value") || err != nil {
+		t.Fatalf("unexpected size: %d, %v", n, err)
+	}
+
+	b := make([]byte, 64)
+	n, err := submit(Getxattr(f.Name(), "user.fd", b)).ReturnInt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "fd value" {
+		t.Fatalf("unexpected value: %q", b[:n])
+	}
+
+	n, err = submit(Fgetxattr(int(f.Fd()), "user.path", b)).ReturnInt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "path value" {
+		t.Fatalf("unexpected value: %q", b[:n])
+	}
+
+	if err := submit(Setxattr(f.Name(), "user.path", []byte("x"), unix.XATTR_CREATE)).Err(); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("unexpected error of the existing attribute: %v", err)
+	}
+	if _, err := submit(Fgetxattr(int(f.Fd()), "user.none", b)).ReturnInt(); !errors.Is(err, unix.ENODATA) {
+		t.Fatalf("unexpected error of the missing attribute: %v", err)
+	}
+}
+
+func TestSyncFileRangeFadvise(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	file, err := os.Create(t.TempDir() + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(make([]byte, 4096)); err != nil {
+		t.Fatal(err)
+	}
+
+	fd := int(file.Fd())
+	ch := make(chan Result, 1)
+	for _, prep := range []PrepRequest{
+		SyncFileRange(fd, 0, 4096, unix.SYNC_FILE_RANGE_WAIT_BEFORE|unix.SYNC_FILE_RANGE_WRITE|unix.SYNC_FILE_RANGE_WAIT_AFTER),
+		Fadvise(fd, 0, 4096, unix.FADV_SEQUENTIAL),
+		Fadvise(fd, 0, 0, unix.FADV_DONTNEED),
+	} {
+		if _, err := iour.SubmitRequest(prep, ch); err != nil {
+			t.Fatal(err)
+		}
+		if err := (<-ch).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// invalid flags are rejected by the kernel
+	if _, err := iour.SubmitRequest(SyncFileRange(fd, 0, 4096, -1), ch); err != nil {
+		t.Fatal(err)
+	}
+	if err := (<-ch).Err(); !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPollAddMultishot(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	ch := make(chan Result, 1)
+	request, err := iour.SubmitRequest(PollAddMultishot(int(r.Fd()), unix.POLLIN), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1)
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write([]byte{'a'}); err != nil {
+			t.Fatal(err)
+		}
+
+		result := <-ch
+		events, err := result.ReturnInt()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if events&unix.POLLIN == 0 {
+			t.Fatalf("unexpected poll events: %x", events)
+		}
+		if !result.HasMore() {
+			t.Fatal("multishot poll is terminated")
+		}
+
+		if _, err := r.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := iour.SubmitRequest(PollRemove(request.ID()), nil); err != nil {
+		t.Fatal(err)
+	}
+	result := <-ch
+	if result.HasMore() {
+		t.Fatal("multishot poll is not terminated")
+	}
+	if err := result.Err(); err != ErrRequestCanceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-request.Done()
+}
+
+func TestProbe(t *testing.T) {
+	iour, err := New(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	probe, err := iour.Probe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []uint8{OpNop, OpRead, OpWrite} {
+		if !probe.IsSupported(op) || !iour.IsSupported(op) {
+			t.Fatalf("opcode %d is not supported", op)
+		}
+	}
+
+	unknown := func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		sqe.PrepOperation(255, -1, 0, 0, 0)
+	}
+	_, err = iour.SubmitRequest(unknown, nil)
+	if !errors.Is(err, ErrOpNotSupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	request, err := iour.SubmitRequest(Nop(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-request.Done()
+}
+
+func TestRecvMultishot(t *testing.T) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fds[0])
+
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	ring, err := iour.RegisterBufferRing(1, 4, 64, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Unregister()
+
+	ch := make(chan Result, 1)
+	if _, err := iour.SubmitRequest(RecvMultishot(fds[0], ring, 0), ch); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []string{"first", "second", "third", "fourth", "fifth"} {
+		if _, err := unix.Write(fds[1], []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+
+		result := <-ch
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if !result.HasMore() {
+			t.Fatal("multishot recv is terminated")
+		}
+		if _, ok := result.BufferID(); !ok {
+			t.Fatal("no buffer is selected")
+		}
+		if b, _ := result.GetRequestBuffer(); string(b) != data {
+			t.Fatalf("unexpected data: %q", b)
+		}
+		result.FreeRequestBuffer()
+	}
+
+	unix.Close(fds[1])
+	result := <-ch
+	if n, err := result.ReturnInt(); n != 0 || err != nil {
+		t.Fatalf("unexpected eof result: %d, %v", n, err)
+	}
+	if result.HasMore() {
+		t.Fatal("multishot recv is not terminated")
+	}
+}
+
+func TestMsgRing(t *testing.T) {
+	source, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	messages := make(chan Message, 1)
+	target, err := New(4, WithMessageChannel(messages))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	request, err := source.SubmitRequest(MsgRing(target, 42, 7), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-request.Done()
+	if err := request.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-messages
+	if message.UserData != 42 || message.Result != 7 {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+}
+
+func TestSubmitRequestCtx(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	request, err := iour.SubmitRequestCtx(ctx, Read(int(r.Fd()), make([]byte, 1)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-request.Done()
+
+	err = request.Err()
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrRequestCanceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSubmitLinkRequestsInStages(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	var expected []byte
+	preqs := make([]PrepRequest, 0, 10)
+	for i := 0; i < 10; i++ {
+		b := []byte{byte('a' + i)}
+		expected = append(expected, b...)
+		preqs = append(preqs, Write(int(w.Fd()), b))
+	}
+
+	requests, err := iour.SubmitLinkRequests(preqs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-requests.Done()
+	if errResults := requests.ErrResults(); errResults != nil {
+		t.Fatal(errResults[0].Err())
+	}
+
+	buf := make([]byte, len(expected))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != string(expected) {
+		t.Fatalf("unexpected order: %s", buf)
+	}
+
+	// the stages which are not submitted are aborted by Cancel of their requests
+	preqs = preqs[:0]
+	for i := 0; i < 8; i++ {
+		preqs = append(preqs, Read(int(r.Fd()), make([]byte, 1)))
+	}
+	requests, err = iour.SubmitLinkRequests(preqs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := requests.Requests()[6].Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("abcde")); err != nil {
+		t.Fatal(err)
+	}
+	<-requests.Done()
+	for i, request := range requests.Requests() {
+		if err := request.Err(); (i < 4) != (err == nil) {
+			t.Fatalf("unexpected error of request %d: %v", i, err)
+		}
+	}
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "e" {
+		t.Fatalf("unexpected data left in the pipe: %q, %v", buf[:n], err)
+	}
+
+	// the stages which are not submitted are canceled when the ring is closed
+	preqs = preqs[:0]
+	for i := 0; i < 8; i++ {
+		preqs = append(preqs, Read(int(r.Fd()), make([]byte, 1)))
+	}
+	requests, err = iour.SubmitLinkRequests(preqs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iour.Close()
+
+	last := requests.Requests()[len(preqs)-1]
+	select {
+	case <-last.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the last stage is not canceled")
+	}
+	if !errors.Is(last.Err(), ErrRequestCanceled) {
+		t.Fatalf("unexpected error: %v", last.Err())
+	}
+}
+
+func TestSubmitAndWait(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	requests, err := iour.SubmitAndWait([]PrepRequest{Nop(), Nop()}, 2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-requests.Done():
+	default:
+		t.Fatal("requests are not completed")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	requests, err = iour.SubmitAndWait([]PrepRequest{Read(int(r.Fd()), make([]byte, 1))}, 1, 10*time.Millisecond)
+	if err != ErrWaitTimeout {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := w.Write([]byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	<-requests.Done()
+
+	// the results sent to the channel which is received by the waiter don't block the wait
+	ch := make(chan Result)
+	for i := 0; i < 2; i++ {
+		if _, err := iour.SubmitRequest(Nop(), ch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := iour.SubmitAndWait([]PrepRequest{Nop()}, 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		<-ch
+	}
+}
+
+func TestRawMode(t *testing.T) {
+	iour, err := New(4, WithRawMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	if _, err := iour.SubmitRequest(Nop(), nil); err != ErrRawMode {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sqe := iour.GetSQE()
+	sqe.PrepOperation(iouring_syscall.IORING_OP_NOP, -1, 0, 0, 0)
+	sqe.SetUserData(42)
+	if _, err := iour.Submit(); err != nil {
+		t.Fatal(err)
+	}
+
+	cqe, err := iour.WaitCQE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cqe.UserData() != 42 || cqe.Result() != 0 {
+		t.Fatalf("unexpected cqe: user data %d, result %d", cqe.UserData(), cqe.Result())
+	}
+	iour.AdvanceCQ(1)
+}
+
+func TestSendRecvFds(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	send, err := SendFds(fds[0], []byte("fd"), []int{int(w.Fd())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2)
+	recv, err := RecvFds(fds[1], buf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests, err := iour.SubmitRequests([]PrepRequest{send, recv}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-requests.Done()
+
+	result := requests.Requests()[1]
+	if n, err := result.ReturnInt(); err != nil || n != 2 {
+		t.Fatalf("unexpected result: %d, %v", n, err)
+	}
+	received, ok := result.ReturnValue1().([]int)
+	if !ok || len(received) != 1 {
+		t.Fatalf("unexpected fds: %v", result.ReturnValue1())
+	}
+
+	file := os.NewFile(uintptr(received[0]), "pipe")
+	defer file.Close()
+	if _, err := file.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(buf[:1]); err != nil || buf[0] != 1 {
+		t.Fatalf("unexpected read: %v, %v", buf[0], err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	file, err := iour.OpenFile(t.TempDir()+"/file", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := io.WriteString(file, "hello iouring"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteAt([]byte("HELLO"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(file); err != nil || string(data) != "HELLO iouring" {
+		t.Fatalf("unexpected read: %q, %v", data, err)
+	}
+
+	b := make([]byte, 7)
+	if n, err := file.ReadAt(b, 6); err != nil || string(b[:n]) != "iouring" {
+		t.Fatalf("unexpected read: %q, %v", b[:n], err)
+	}
+	if _, err := file.ReadAt(b, 10); err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var perr *os.PathError
+	if _, err := file.ReadAt(b, -1); !errors.As(err, &perr) || perr.Op != "readat" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := file.Truncate(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := file.Stat(); err != nil || fi.Size() != 5 || fi.Name() != "file" || !fi.Mode().IsRegular() {
+		t.Fatalf("unexpected stat: %v", err)
+	}
+	if offset, err := file.Seek(0, io.SeekEnd); err != nil || offset != 5 {
+		t.Fatalf("unexpected offset: %d, %v", offset, err)
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Read(b); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := file.ReadAt(b, 0); !errors.As(err, &perr) || perr.Op != "readat" || !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := file.WriteAt(b, 0); !errors.As(err, &perr) || perr.Op != "writeat" || !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the offset is moved to the end of the file by the append writes
+	file, err = iour.OpenFile(file.Name(), os.O_RDWR|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(file, " uring"); err != nil {
+		t.Fatal(err)
+	}
+	if offset, err := file.Seek(0, io.SeekCurrent); err != nil || offset != 11 {
+		t.Fatalf("unexpected offset: %d, %v", offset, err)
+	}
+	if n, err := file.Read(b); n != 0 || err != io.EOF {
+		t.Fatalf("unexpected read: %d, %v", n, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(file); err != nil || string(data) != "HELLO uring" {
+		t.Fatalf("unexpected read: %q, %v", data, err)
+	}
+}
+
+func TestFileCloseWhileWriting(t *testing.T) {
+	iour, err := New(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	dir := t.TempDir()
+	file, err := iour.OpenFile(dir+"/file", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			b := make([]byte, 4096)
+			for {
+				if _, err := file.WriteAt(b, 0); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the closed fd is likely reused by the new file, and the writers must not write into it
+	reused, err := os.Create(dir + "/reused")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reused.Close()
+
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; !errors.Is(err, os.ErrClosed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if fi, err := reused.Stat(); err != nil || fi.Size() != 0 {
+		t.Fatalf("unexpected write to the reused fd: %v", err)
+	}
+}
+
+func TestWithContinuation(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	ch := make(chan Result, 1)
+	b := make([]byte, 8)
+	if _, err := iour.SubmitRequest(Read(int(r.Fd()), b).WithContinuation(), ch); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"io", "_ur", "ing!"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result := <-ch
+	if n, err := result.ReturnInt(); err != nil || n != len(b) || string(b) != "io_uring" {
+		t.Fatalf("unexpected result: %d, %v, %q", n, err, b)
+	}
+
+	// the end of file stops the continuation
+	file, err := os.Create(t.TempDir() + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := iour.SubmitRequest(WriteAll(int(file.Fd()), []byte("iouring"), 0), ch); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := (<-ch).ReturnInt(); err != nil || n != 7 {
+		t.Fatalf("unexpected result: %d, %v", n, err)
+	}
+	if _, err := iour.SubmitRequest(ReadFull(int(file.Fd()), b, 2), ch); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := (<-ch).ReturnInt(); err != nil || n != 5 || string(b[:n]) != "uring" {
+		t.Fatalf("unexpected result: %d, %v", n, err)
+	}
+
+	// the bytes read before the error are reported by the second return value
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	request, err := iour.SubmitRequest(Read(int(pr.Fd()), b).WithContinuation(), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pw.Write([]byte("io")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := request.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+
+	result = <-ch
+	if err := result.Err(); !errors.Is(err, ErrRequestCanceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, ok := result.ReturnValue1().(int); !ok || n != 2 || string(b[:n]) != "io" {
+		t.Fatalf("unexpected partial result: %v, %q", result.ReturnValue1(), b)
+	}
+
+	// the request canceled between the short read and the resubmission is aborted
+	request, err = iour.SubmitRequest(Read(int(pr.Fd()), b).WithContinuation(), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iour.submitLock.Lock()
+	go request.Cancel()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := pw.Write([]byte("io")); err != nil {
+		iour.submitLock.Unlock()
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// the cancel request waiting longer is submitted before the resubmission, and finds nothing
+	iour.submitLock.Unlock()
+
+	select {
+	case result = <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("the canceled request is resubmitted")
+	}
+	if err := result.Err(); !errors.Is(err, ErrRequestCanceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, ok := result.ReturnValue1().(int); !ok || n != 2 {
+		t.Fatalf("unexpected partial result: %v", result.ReturnValue1())
+	}
+}
+
+func TestSubmissionQueueRollback(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	iour.submitLock.Lock()
+	defer iour.submitLock.Unlock()
+
+	for i := 0; i < 2; i++ {
+		iour.getSQEntry().PrepOperation(iouring_syscall.IORING_OP_NOP, -1, 0, 0, 0)
+	}
+	if pending := iour.sq.flush(); pending != 2 {
+		t.Fatalf("unexpected pending entries: %d", pending)
+	}
+	if n := iour.sq.rollback(); n != 2 {
+		t.Fatalf("unexpected dropped entries: %d", n)
+	}
+	if pending := iour.sq.flush(); pending != 0 {
+		t.Fatalf("unexpected pending entries: %d", pending)
+	}
+}
+
+func TestReserveDirectFiles(t *testing.T) {
+	iour, err := New(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if err := iour.RegisterFile(r); err != nil {
+		t.Fatal(err)
+	}
+	if err := iour.ReserveDirectFiles(2); err != nil {
+		t.Skipf("direct files are not supported: %v", err)
+	}
+	register := iour.fileRegister.(*fileRegister)
+
+	// the registered files use the free slots before the reserved slots
+	if err := iour.RegisterFiles([]*os.File{w}); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []*os.File{r, w} {
+		if index, ok := iour.GetFixedFileIndex(file); !ok || index >= register.directOffset {
+			t.Fatalf("unexpected index of the registered file: %d, %v", index, ok)
+		}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	lfile, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lfile.Close()
+
+	ch := make(chan Result, 1)
+	accept, err := iour.SubmitRequest(AcceptMultishotDirect(int(lfile.Fd()), 0), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	index, err := (<-ch).ReturnInt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index < register.directOffset || index >= register.directOffset+register.directs {
+		t.Fatalf("unexpected direct file index: %d", index)
+	}
+
+	if _, err := client.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 1)
+	request, err := iour.SubmitRequest(Read(index, b).WithFixedFile(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-request.Done()
+	if n, err := request.ReturnInt(); err != nil || n != 1 || b[0] != 'x' {
+		t.Fatalf("unexpected read from the direct file: %d, %v", n, err)
+	}
+
+	if _, err := accept.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	for result := range ch {
+		if !result.HasMore() {
+			break
+		}
+	}
+}
+
+func TestRegisterFiles(t *testing.T) {
+	iour, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iour.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	// the file set grows when there is no free slot
+	for _, file := range []*os.File{r, w} {
+		if err := iour.RegisterFile(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if index, ok := iour.GetFixedFileIndex(w); !ok || index != 1 {
+		t.Fatalf("unexpected index: %d, %v", index, ok)
+	}
+
+	// the free slot is reused
+	if err := iour.UnregisterFile(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := iour.GetFixedFileIndex(r); ok {
+		t.Fatal("file is not unregistered")
+	}
+	if err := iour.RegisterFile(r); err != nil {
+		t.Fatal(err)
+	}
+	if index, ok := iour.GetFixedFileIndex(r); !ok || index != 0 {
+		t.Fatalf("unexpected index: %d, %v", index, ok)
+	}
+}
+
+func TestMoveDirectFile(t *testing.T) {
+	source, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	messages := make(chan Message, 1)
+	target, err := New(4, WithMessageChannel(messages))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	if err := target.ReserveDirectFiles(2); err != nil {
+		t.Skipf("direct files are not supported: %v", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if err := source.RegisterFile(w); err != nil {
+		t.Fatal(err)
+	}
+	index, _ := source.GetFixedFileIndex(w)
+
+	if _, err := MsgRingFd(target, index, 0, 42); err == nil {
+		t.Fatal("target index out of the reserved direct files is accepted")
+	}
+
+	targetIndex, err := source.MoveDirectFile(target, index, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := source.GetFixedFileIndex(w); ok {
+		t.Fatal("moved file is still registered")
+	}
+	if message := <-messages; message.UserData != 42 || int(message.Result) != targetIndex {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+
+	request, err := target.SubmitRequest(Write(targetIndex, []byte("x")).WithFixedFile(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-request.Done()
+	if err := request.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := make([]byte, 1)
+	if _, err := r.Read(b); err != nil || b[0] != 'x' {
+		t.Fatalf("unexpected read: %q, %v", b, err)
+	}
+}
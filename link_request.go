@@ -4,7 +4,6 @@
 package iouring
 
 import (
-	"errors"
 	"time"
 	"unsafe"
 
@@ -22,21 +21,20 @@ func (iour *IOURing) SubmitHardLinkRequests(requests []PrepRequest, ch chan<- Re
 }
 
 func (iour *IOURing) submitLinkRequest(requests []PrepRequest, ch chan<- Result, hard bool) (RequestSet, error) {
-	// TODO(iceber): no length limit
-	if len(requests) > int(*iour.sq.entries) {
-		return nil, errors.New("too many requests")
-	}
-
 	flags := iouring_syscall.IOSQE_FLAGS_IO_LINK
 	if hard {
 		flags = iouring_syscall.IOSQE_FLAGS_IO_HARDLINK
 	}
 
+	if len(requests) > int(*iour.sq.entries) {
+		return iour.submitLinkRequestInStages(requests, ch, flags)
+	}
+
 	iour.submitLock.Lock()
 	defer iour.submitLock.Unlock()
 
-	if iour.IsClosed() {
-		return nil, ErrIOURingClosed
+	if err := iour.checkSubmit(); err != nil {
+		return nil, err
 	}
 
 	var sqeN uint32
@@ -82,6 +80,113 @@ func (iour *IOURing) submitLinkRequest(requests []PrepRequest, ch chan<- Result,
 	return rset, nil
 }
 
+// submitLinkRequestInStages submits the link chain which is longer than the entries of the submission queue,
+// the chain is split into stages, and the next stage is submitted after the last request of
+// the previous stage is completed, if the chain is broken, the rest requests are canceled
+func (iour *IOURing) submitLinkRequestInStages(requests []PrepRequest, ch chan<- Result, flags uint8) (RequestSet, error) {
+	iour.submitLock.Lock()
+	defer iour.submitLock.Unlock()
+
+	if err := iour.checkSubmit(); err != nil {
+		return nil, err
+	}
+
+	sqes, userDatas, err := iour.prepareRequests(requests, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := int(*iour.sq.entries)
+
+	var ends []int
+	for start := 0; start < len(sqes); {
+		end := start + entries
+		if end >= len(sqes) {
+			end = len(sqes)
+		} else if sqes[end].Opcode() == iouring_syscall.IORING_OP_LINK_TIMEOUT && end-1 > start {
+			// link timeout must be in the same stage with the linked request
+			end--
+		}
+
+		for i := start; i < end; i++ {
+			sqes[i].CleanFlags(iouring_syscall.IOSQE_FLAGS_IO_HARDLINK | iouring_syscall.IOSQE_FLAGS_IO_LINK)
+			if i < end-1 {
+				sqes[i].SetFlags(flags)
+			}
+		}
+		ends = append(ends, end)
+		start = end
+	}
+
+	rset := newRequestSet(userDatas)
+
+	iour.userDataLock.Lock()
+	for _, data := range userDatas {
+		iour.userDatas[data.id] = data
+	}
+	iour.userDataLock.Unlock()
+
+	if err := iour.submitStage(sqes[:ends[0]]); err != nil {
+		iour.userDataLock.Lock()
+		for _, data := range userDatas {
+			delete(iour.userDatas, data.id)
+		}
+		iour.userDataLock.Unlock()
+
+		return nil, err
+	}
+
+	go iour.submitLinkStages(sqes, userDatas, ends, flags == iouring_syscall.IOSQE_FLAGS_IO_HARDLINK)
+	return rset, nil
+}
+
+func (iour *IOURing) submitLinkStages(sqes []iouring_syscall.SubmissionQueueEntry, userDatas []*UserData, ends []int, hard bool) {
+	for k := 1; k < len(ends); k++ {
+		start, end := ends[k-1], ends[k]
+
+		last := start - 1
+		if sqes[last].Opcode() == iouring_syscall.IORING_OP_LINK_TIMEOUT {
+			last--
+		}
+
+		// the in-flight requests are not completed after the ring is closed
+		req := userDatas[last].request
+		select {
+		case <-req.done:
+		case <-iour.closer:
+			iour.abortRequests(userDatas[start:])
+			return
+		}
+		if !hard && req.res < 0 {
+			iour.abortRequests(userDatas[start:])
+			return
+		}
+
+		// requests are canceled by Cancel or the context before they are submitted,
+		// the flags are checked under the submitLock, so the cancel request is submitted
+		// after the stage if the flag is set later
+		iour.submitLock.Lock()
+		err := iour.checkSubmit()
+		if err == nil {
+			for _, data := range userDatas[start:] {
+				if data.request.isCanceled() {
+					err = ErrRequestCanceled
+					break
+				}
+			}
+		}
+		if err == nil {
+			err = iour.submitStage(sqes[start:end])
+		}
+		iour.submitLock.Unlock()
+
+		if err != nil {
+			iour.abortRequests(userDatas[start:])
+			return
+		}
+	}
+}
+
 func linkTimeout(t time.Duration) PrepRequest {
 	timespec := unix.NsecToTimespec(t.Nanoseconds())
 
@@ -0,0 +1,108 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"errors"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// Message is the completion event posted by MsgRing or MsgRingFd from other ring,
+// it is received by the channel set by WithMessageChannel
+type Message struct {
+	UserData uint64
+	Result   int32
+	Flags    uint32
+}
+
+// MsgRing posts a completion event with the userData and res to the target ring,
+// userData must not be the ID of the request submitted to the target ring
+// Available since 5.18
+func MsgRing(target *IOURing, userData uint64, res int32) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, data *UserData) {
+		data.rawFd = true
+		data.hold(target)
+		data.request.resolver = errResolver
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_MSG_RING,
+			int32(target.fd),
+			iouring_syscall.IORING_MSG_DATA,
+			uint32(res),
+			userData,
+		)
+	}
+}
+
+// MsgRingFd sends the direct descriptor in the slot index of the ring to the slot
+// targetIndex of the target ring, the target ring receives a message with the userData.
+// targetIndex must be one of the slots reserved by IOURing.ReserveDirectFiles of the target ring,
+// so it's not taken by the files registered to the target ring.
+// The slot of the ring is not closed, use IOURing.MoveDirectFile to move the file
+// Available since 6.0
+func MsgRingFd(target *IOURing, index int, targetIndex int, userData uint64) (PrepRequest, error) {
+	register, ok := target.fileRegister.(*fileRegister)
+	if !ok || !register.isDirectSlot(targetIndex) {
+		return nil, errors.New("target index is not in the reserved direct files of the target ring")
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, data *UserData) {
+		prepMsgRingFd(sqe, data, target, index, uint32(targetIndex+1), userData)
+		data.request.resolver = errResolver
+	}, nil
+}
+
+// MsgRingFdAlloc sends the direct descriptor in the slot index of the ring to a slot
+// allocated from the reserved direct files of the target ring, the result value
+// and the Result of the message received by the target ring is the index of the allocated slot
+// Available since 6.0
+func MsgRingFdAlloc(target *IOURing, index int, userData uint64) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, data *UserData) {
+		prepMsgRingFd(sqe, data, target, index, iouring_syscall.IORING_FILE_INDEX_ALLOC, userData)
+		data.request.resolver = fdResolver
+	}
+}
+
+// MoveDirectFile moves the file in the slot index of the ring to a slot allocated from the
+// reserved direct files of the target ring, and returns the index of the allocated slot.
+// The slot of the ring is closed after the file is sent, and the file registered in the slot
+// is unregistered, the target ring receives a message with the userData
+// Available since 6.0
+func (iour *IOURing) MoveDirectFile(target *IOURing, index int, userData uint64) (int, error) {
+	set, err := iour.SubmitLinkRequests([]PrepRequest{MsgRingFdAlloc(target, index, userData), CloseDirect(index)}, nil)
+	if err != nil {
+		return -1, err
+	}
+	<-set.Done()
+
+	requests := set.Requests()
+	targetIndex, err := requests[0].ReturnInt()
+	if err != nil {
+		return -1, err
+	}
+	if err := requests[1].Err(); err != nil {
+		return targetIndex, err
+	}
+
+	if register, ok := iour.fileRegister.(*fileRegister); ok {
+		register.releaseSlot(index)
+	}
+	return targetIndex, nil
+}
+
+func prepMsgRingFd(sqe iouring_syscall.SubmissionQueueEntry, data *UserData, target *IOURing, index int, fileIndex uint32, userData uint64) {
+	data.rawFd = true
+	data.hold(target)
+
+	sqe.PrepOperation(
+		iouring_syscall.IORING_OP_MSG_RING,
+		int32(target.fd),
+		iouring_syscall.IORING_MSG_SEND_FD,
+		0,
+		userData,
+	)
+	sqe.SetAddr3(uint64(index))
+	sqe.SetFileIndex(fileIndex)
+}
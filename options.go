@@ -66,7 +66,8 @@ func WithAsync() IOURingOption {
 }
 
 // WithDisableRing the io_uring ring starts in a disabled state
-// In this state, restrictions can be registered, but submissions are not allowed
+// In this state, restrictions can be registered by RegisterRestrictions,
+// but submissions are not allowed until the ring is enabled by Enable
 // Available since 5.10
 func WithDisableRing() IOURingOption {
 	return func(iour *IOURing) {
@@ -74,6 +75,23 @@ func WithDisableRing() IOURingOption {
 	}
 }
 
+// WithMessageChannel the completion events posted by MsgRing or MsgRingFd
+// from other rings are sent to the channel as Message
+func WithMessageChannel(ch chan<- Message) IOURingOption {
+	return func(iour *IOURing) {
+		iour.messages = ch
+	}
+}
+
+// WithRawMode the ring is driven by the user without the background goroutine and eventfd,
+// requests are submitted by GetSQE and Submit, and completion events are reaped by
+// PeekCQE, WaitCQE and AdvanceCQ. SubmitRequest and other high-level APIs return ErrRawMode
+func WithRawMode() IOURingOption {
+	return func(iour *IOURing) {
+		iour.raw = true
+	}
+}
+
 // WithDrain every SQE will not be started before previously submitted SQEs have completed
 func WithDrain() IOURingOption {
 	return func(iour *IOURing) {
@@ -0,0 +1,26 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// RegisterPersonality registers the credentials of the calling thread,
+// and returns the personality id used by PrepRequest.WithPersonality.
+// Credentials are per-thread in the kernel, the goroutine should be locked
+// to its thread by runtime.LockOSThread if the thread credentials are changed
+// Available since 5.6
+func (iour *IOURing) RegisterPersonality() (uint16, error) {
+	id, err := iouring_syscall.IOURingRegisterWithResult(iour.fd, iouring_syscall.IORING_REGISTER_PERSONALITY, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(id), nil
+}
+
+// UnregisterPersonality unregisters the personality by id
+func (iour *IOURing) UnregisterPersonality(id uint16) error {
+	return iouring_syscall.IOURingRegister(iour.fd, iouring_syscall.IORING_UNREGISTER_PERSONALITY, nil, uint32(id))
+}
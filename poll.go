@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// PollAdd request is completed when the fd is ready for the poll events,
+// the result value is the mask of the returned events
+func PollAdd(fd int, events uint32) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_POLL_ADD, int32(fd), 0, 0, 0)
+		sqe.SetOpFlags(events)
+	}
+}
+
+// PollAddMultishot posts a result every time the fd is ready for the poll events,
+// the request stays active until it is removed or canceled.
+// Result.HasMore reports whether more results will follow
+// Available since 5.13
+func PollAddMultishot(fd int, events uint32) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_POLL_ADD,
+			int32(fd),
+			0,
+			iouring_syscall.IORING_POLL_ADD_MULTI,
+			0,
+		)
+		sqe.SetOpFlags(events)
+	}
+}
+
+// PollRemove removes the poll request by request id
+func PollRemove(id uint64) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = pollRemoveResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_POLL_REMOVE, -1, id, 0, 0)
+	}
+}
@@ -38,6 +38,24 @@ func (prepReq PrepRequest) WithCallback(callback RequestCallback) PrepRequest {
 	}
 }
 
+// WithFixedFile the fd of the request is the index of the registered file set,
+// such as the direct descriptor allocated by AcceptMultishotDirect
+func (prepReq PrepRequest) WithFixedFile() PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		prepReq(sqe, userData)
+		userData.rawFd = true
+		sqe.SetFlags(iouring_syscall.IOSQE_FLAGS_FIXED_FILE)
+	}
+}
+
+// WithPersonality request is issued with the credentials of the registered personality
+func (prepReq PrepRequest) WithPersonality(id uint16) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		prepReq(sqe, userData)
+		sqe.SetPersonality(id)
+	}
+}
+
 func (iour *IOURing) Read(file *os.File, b []byte, ch chan<- Result) (Request, error) {
 	fd := int(file.Fd())
 	if fd < 0 {
@@ -285,6 +303,33 @@ func Send(sockfd int, b []byte, flags int) PrepRequest {
 	}
 }
 
+// SendZC sends the buffer without copying it, the buffer must not be modified
+// until Request.BufferReleased is closed, which may happen after the request is done
+// Available since 6.0
+func SendZC(sockfd int, b []byte, flags int) PrepRequest {
+	var bp unsafe.Pointer
+	if len(b) > 0 {
+		bp = unsafe.Pointer(&b[0])
+	} else {
+		bp = unsafe.Pointer(&_zero)
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+		userData.request.released = make(chan struct{})
+		userData.SetRequestBuffer(b, nil)
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_SEND_ZC,
+			int32(sockfd),
+			uint64(uintptr(bp)),
+			uint32(len(b)),
+			0,
+		)
+		sqe.SetOpFlags(uint32(flags))
+	}
+}
+
 func Recv(sockfd int, b []byte, flags int) PrepRequest {
 	var bp unsafe.Pointer
 	if len(b) > 0 {
@@ -308,6 +353,25 @@ func Recv(sockfd int, b []byte, flags int) PrepRequest {
 }
 
 func Sendmsg(sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepRequest, error) {
+	return sendmsg(iouring_syscall.IORING_OP_SENDMSG, sockfd, p, oob, to, flags)
+}
+
+// SendmsgZC sends the message without copying the data, the data must not be modified
+// until Request.BufferReleased is closed, which may happen after the request is done
+// Available since 6.1
+func SendmsgZC(sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepRequest, error) {
+	prep, err := sendmsg(iouring_syscall.IORING_OP_SENDMSG_ZC, sockfd, p, oob, to, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		prep(sqe, userData)
+		userData.request.released = make(chan struct{})
+	}, nil
+}
+
+func sendmsg(op uint8, sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepRequest, error) {
 	var ptr unsafe.Pointer
 	var salen uint32
 	if to != nil {
@@ -365,16 +429,26 @@ func Sendmsg(sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepReq
 		userData.request.resolver = resolver
 		userData.SetRequestBuffer(p, oob)
 
-		sqe.PrepOperation(iouring_syscall.IORING_OP_SENDMSG, int32(sockfd), uint64(uintptr(msgptr)), 1, 0)
+		sqe.PrepOperation(op, int32(sockfd), uint64(uintptr(msgptr)), 1, 0)
 		sqe.SetOpFlags(uint32(flags))
 	}, nil
 }
 
+// RecvmsgInfo is the ReturnValue1 of the Recvmsg request
+type RecvmsgInfo struct {
+	// Oobn is the length of the control messages received in the oob buffer
+	Oobn int
+	// Flags is the flags of the received message, like syscall.MSG_TRUNC
+	Flags int
+	// From is the source address, it is nil for the connected socket
+	From syscall.Sockaddr
+}
+
+// Recvmsg receives a message from the socket,
+// the ReturnValue0 is the number of bytes received, and the ReturnValue1 is *RecvmsgInfo
 func Recvmsg(sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepRequest, error) {
 	var msg syscall.Msghdr
 	var rsa syscall.RawSockaddrAny
-	msg.Name = (*byte)(unsafe.Pointer(&rsa))
-	msg.Namelen = uint32(syscall.SizeofSockaddrAny)
 	var iov syscall.Iovec
 	if len(p) > 0 {
 		iov.Base = &p[0]
@@ -395,7 +469,6 @@ func Recvmsg(sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepReq
 			}
 		}
 		msg.Control = &oob[0]
-		msg.SetControllen(len(oob))
 	}
 	msg.Iov = &iov
 	msg.Iovlen = 1
@@ -411,6 +484,12 @@ func Recvmsg(sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepReq
 		if len(oob) > 0 && len(p) == 0 {
 			result.r0 = 0
 		}
+
+		info := &RecvmsgInfo{Oobn: int(msg.Controllen), Flags: int(msg.Flags)}
+		if msg.Namelen > 0 && rsa.Addr.Family != syscall.AF_UNSPEC {
+			info.From, _ = anyToSockaddr(&rsa)
+		}
+		result.r1 = info
 	}
 
 	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
@@ -418,6 +497,13 @@ func Recvmsg(sockfd int, p, oob []byte, to syscall.Sockaddr, flags int) (PrepReq
 		userData.request.resolver = resolver
 		userData.SetRequestBuffer(p, oob)
 
+		// msghdr is updated by the kernel, reset it for every submission
+		rsa = syscall.RawSockaddrAny{}
+		msg.Name = (*byte)(unsafe.Pointer(&rsa))
+		msg.Namelen = uint32(syscall.SizeofSockaddrAny)
+		msg.SetControllen(len(oob))
+		msg.Flags = 0
+
 		sqe.PrepOperation(
 			iouring_syscall.IORING_OP_RECVMSG,
 			int32(sockfd),
@@ -494,6 +580,35 @@ func Accept4(sockfd int, flags int) PrepRequest {
 	}
 }
 
+// AcceptMultishot posts a result for every accepted connection,
+// the request stays active until it is canceled or an error occurs.
+// Result.HasMore reports whether more results will follow
+// Available since 5.19
+func AcceptMultishot(sockfd int, flags int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_ACCEPT, int32(sockfd), 0, 0, 0)
+		sqe.SetOpFlags(uint32(flags))
+		sqe.SetIoprio(iouring_syscall.IORING_ACCEPT_MULTISHOT)
+	}
+}
+
+// AcceptMultishotDirect is AcceptMultishot, but the accepted connections are installed
+// into the direct file slots reserved by IOURing.ReserveDirectFiles instead of normal fds,
+// the result value is the index of the slot, used by PrepRequest.WithFixedFile
+// Available since 5.19
+func AcceptMultishotDirect(sockfd int, flags int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_ACCEPT, int32(sockfd), 0, 0, 0)
+		sqe.SetOpFlags(uint32(flags))
+		sqe.SetIoprio(iouring_syscall.IORING_ACCEPT_MULTISHOT)
+		sqe.SetFileIndex(iouring_syscall.IORING_FILE_INDEX_ALLOC)
+	}
+}
+
 func Connect(sockfd int, sa syscall.Sockaddr) (PrepRequest, error) {
 	ptr, n, err := sockaddr(sa)
 	if err != nil {
@@ -514,6 +629,86 @@ func Connect(sockfd int, sa syscall.Sockaddr) (PrepRequest, error) {
 	}, nil
 }
 
+// Socket creates a socket, the result value is the fd of the socket
+// Available since 5.19
+func Socket(domain, typ, proto int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_SOCKET, int32(domain), 0, uint32(proto), uint64(typ))
+	}
+}
+
+// SocketDirect creates a socket and installs it into the slot of the registered file set,
+// the slot should be a free one of the slots reserved by IOURing.ReserveDirectFiles,
+// then the socket is used by PrepRequest.WithFixedFile with the slot, even in a linked request chain
+// Available since 5.19
+func SocketDirect(domain, typ, proto int, index int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_SOCKET, int32(domain), 0, uint32(proto), uint64(typ))
+		sqe.SetFileIndex(uint32(index + 1))
+	}
+}
+
+// SocketDirectAlloc creates a socket and installs it into a slot allocated by the kernel
+// from the slots reserved by IOURing.ReserveDirectFiles, the result value is the index of the slot
+// Available since 5.19
+func SocketDirectAlloc(domain, typ, proto int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_SOCKET, int32(domain), 0, uint32(proto), uint64(typ))
+		sqe.SetFileIndex(iouring_syscall.IORING_FILE_INDEX_ALLOC)
+	}
+}
+
+// Shutdown shuts down the full-duplex connection of the socket
+// Available since 5.11
+func Shutdown(fd int, how int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_SHUTDOWN, int32(fd), 0, uint32(how), 0)
+	}
+}
+
+// Bind binds the address to the socket
+// Available since 6.11
+func Bind(fd int, sa syscall.Sockaddr) (PrepRequest, error) {
+	ptr, n, err := sockaddr(sa)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.hold(sa)
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_BIND,
+			int32(fd),
+			uint64(uintptr(ptr)),
+			0,
+			uint64(n),
+		)
+	}, nil
+}
+
+// Listen marks the socket as a passive socket to accept connections
+// Available since 6.11
+func Listen(fd int, backlog int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_LISTEN, int32(fd), 0, uint32(backlog), 0)
+	}
+}
+
 func Openat(dirfd int, path string, flags uint32, mode uint32) (PrepRequest, error) {
 	flags |= syscall.O_LARGEFILE
 	b, err := syscall.ByteSliceFromString(path)
@@ -609,6 +804,38 @@ func Fallocate(fd int, mode uint32, off int64, length int64) PrepRequest {
 	}
 }
 
+// Ftruncate truncates the file to the length, see ftruncate(2)
+// Available since 6.9
+func Ftruncate(fd int, length int64) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_FTRUNCATE, int32(fd), 0, 0, uint64(length))
+	}
+}
+
+// SyncFileRange syncs the file range with the disk, see sync_file_range(2)
+// Available since 5.2
+func SyncFileRange(fd int, off int64, n uint32, flags int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_SYNC_FILE_RANGE, int32(fd), 0, n, uint64(off))
+		sqe.SetOpFlags(uint32(flags))
+	}
+}
+
+// Fadvise announces the access pattern for the file data, see posix_fadvise(2)
+// Available since 5.6
+func Fadvise(fd int, off int64, n uint32, advice int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_FADVISE, int32(fd), 0, n, uint64(off))
+		sqe.SetOpFlags(uint32(advice))
+	}
+}
+
 func Close(fd int) PrepRequest {
 	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
 		userData.request.resolver = errResolver
@@ -616,6 +843,18 @@ func Close(fd int) PrepRequest {
 	}
 }
 
+// CloseDirect closes the direct descriptor in the slot of the registered file set
+// Available since 5.15
+func CloseDirect(index int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = errResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_CLOSE, 0, 0, 0, 0)
+		sqe.SetFileIndex(uint32(index + 1))
+	}
+}
+
 func Madvise(b []byte, advice int) PrepRequest {
 	var bp unsafe.Pointer
 	if len(b) > 0 {
@@ -652,6 +891,44 @@ func EpollCtl(epfd int, op int, fd int, event *syscall.EpollEvent) PrepRequest {
 	}
 }
 
+// Splice moves n bytes from fdIn to fdOut without copying between kernel and user space,
+// one of the fds must refer to a pipe, the offset of the pipe must be -1.
+// Registered files are used by their index
+func Splice(fdIn int, offIn int64, fdOut int, offOut int64, n uint32, flags uint32) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_SPLICE,
+			int32(fdOut),
+			uint64(offIn),
+			n,
+			uint64(offOut),
+		)
+		prepSpliceFdIn(sqe, userData, fdIn, flags)
+	}
+}
+
+// Tee duplicates n bytes from the pipe fdIn to the pipe fdOut without consuming them
+func Tee(fdIn int, fdOut int, n uint32, flags uint32) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_TEE, int32(fdOut), 0, n, 0)
+		prepSpliceFdIn(sqe, userData, fdIn, flags)
+	}
+}
+
+func prepSpliceFdIn(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData, fdIn int, flags uint32) {
+	if index, ok := userData.request.iour.fileRegister.GetFileIndex(int32(fdIn)); ok {
+		fdIn = index
+		flags |= iouring_syscall.IOSQE_SPLICE_F_FD_IN_FIXED
+	}
+
+	sqe.SetSpliceFdIn(int32(fdIn))
+	sqe.SetOpFlags(flags)
+}
+
 func Mkdirat(dirFd int, path string, mode uint32) (PrepRequest, error) {
 	b, err := syscall.ByteSliceFromString(path)
 	if err != nil {
@@ -1,3 +1,69 @@
+//go:build linux
 // +build linux
 
 package iouring
+
+import (
+	"unsafe"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// Probe is the set of opcodes supported by the running kernel
+type Probe struct {
+	lastOp uint8
+	ops    []iouring_syscall.IOURingProbeOp
+}
+
+// Probe returns the opcodes supported by the running kernel
+// Available since 5.6
+func (iour *IOURing) Probe() (*Probe, error) {
+	probe := &iouring_syscall.IOURingProbe{}
+	if err := iouring_syscall.IOURingRegister(
+		iour.fd,
+		iouring_syscall.IORING_REGISTER_PROBE,
+		unsafe.Pointer(probe),
+		uint32(len(probe.Ops)),
+	); err != nil {
+		return nil, err
+	}
+
+	return &Probe{lastOp: probe.LastOp, ops: probe.Ops[:probe.OpsLen]}, nil
+}
+
+// IsSupported reports whether the opcode is supported by the running kernel,
+// it's always true if the kernel can not be probed
+func (iour *IOURing) IsSupported(op uint8) bool {
+	if iour.probe == nil {
+		return true
+	}
+	return iour.probe.IsSupported(op)
+}
+
+// LastOp returns the last opcode known by the running kernel
+func (probe *Probe) LastOp() uint8 {
+	return probe.lastOp
+}
+
+func (probe *Probe) IsSupported(op uint8) bool {
+	return probe.Flags(op)&iouring_syscall.IO_URING_OP_SUPPORTED != 0
+}
+
+// Flags returns the flags of the opcode
+func (probe *Probe) Flags(op uint8) uint16 {
+	if int(op) >= len(probe.ops) {
+		return 0
+	}
+	return probe.ops[op].Flags
+}
+
+// SupportedOps returns all opcodes supported by the running kernel
+func (probe *Probe) SupportedOps() []uint8 {
+	ops := make([]uint8, 0, len(probe.ops))
+	for _, op := range probe.ops {
+		if op.Flags&iouring_syscall.IO_URING_OP_SUPPORTED != 0 {
+			ops = append(ops, op.Op)
+		}
+	}
+	return ops
+}
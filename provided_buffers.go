@@ -0,0 +1,208 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"errors"
+	"math"
+	"unsafe"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// ProvidedBuffers is a group of buffers provided to the kernel,
+// requests with the provided buffers select a buffer when the data is ready.
+// It's implemented by BufferGroup and BufferRing
+type ProvidedBuffers interface {
+	ID() uint16
+	BufferSize() int
+	Buffer(bid uint16) []byte
+	ReleaseBuffer(bid uint16) error
+
+	selectBuffer(flags uint32, res int32) []byte
+	releaseSelected(flags uint32) error
+}
+
+var _ ProvidedBuffers = &BufferGroup{}
+
+// BufferGroup is a group of buffers provided by IORING_OP_PROVIDE_BUFFERS requests,
+// every released buffer is provided again by a request
+type BufferGroup struct {
+	iour *IOURing
+
+	id      uint16
+	size    int
+	count   int
+	buffers []byte
+}
+
+// ProvideBufferGroup provides count buffers of size bytes to the kernel under the group id,
+// the buffer ids are 16-bit, so the count is at most 65536
+// Available since 5.7
+func (iour *IOURing) ProvideBufferGroup(id uint16, count int, size int) (*BufferGroup, error) {
+	if count <= 0 || size <= 0 {
+		return nil, errors.New("invalid buffer count or size")
+	}
+	if count > math.MaxUint16+1 {
+		return nil, errors.New("buffer count exceeds the buffer id space")
+	}
+
+	group := &BufferGroup{
+		iour:    iour,
+		id:      id,
+		size:    size,
+		count:   count,
+		buffers: make([]byte, count*size),
+	}
+
+	request, err := iour.SubmitRequest(ProvideBuffers(group.buffers, count, id, 0), nil)
+	if err != nil {
+		return nil, err
+	}
+	<-request.Done()
+	if err := request.Err(); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (group *BufferGroup) ID() uint16 {
+	return group.id
+}
+
+func (group *BufferGroup) BufferSize() int {
+	return group.size
+}
+
+// Buffer returns the buffer by buffer id
+func (group *BufferGroup) Buffer(bid uint16) []byte {
+	offset := int(bid) * group.size
+	return group.buffers[offset : offset+group.size : offset+group.size]
+}
+
+// ReleaseBuffer gives the buffer back to the kernel, so that it can be selected again,
+// it waits until the buffer is provided, the buffer is not in the group if an error is returned
+func (group *BufferGroup) ReleaseBuffer(bid uint16) error {
+	if int(bid) >= group.count {
+		return errors.New("invalid buffer id")
+	}
+
+	request, err := group.iour.SubmitRequest(ProvideBuffers(group.Buffer(bid), 1, group.id, bid), nil)
+	if err != nil {
+		return err
+	}
+	<-request.Done()
+	return request.Err()
+}
+
+// Remove removes the buffers of the group from the kernel
+func (group *BufferGroup) Remove() error {
+	request, err := group.iour.SubmitRequest(RemoveBuffers(group.count, group.id), nil)
+	if err != nil {
+		return err
+	}
+	<-request.Done()
+	return request.Err()
+}
+
+func (group *BufferGroup) selectBuffer(flags uint32, res int32) []byte {
+	b := group.Buffer(uint16(flags >> iouring_syscall.IORING_CQE_BUFFER_SHIFT))
+	if res < 0 {
+		return b[:0]
+	}
+	return b[:res]
+}
+
+func (group *BufferGroup) releaseSelected(flags uint32) error {
+	return group.ReleaseBuffer(uint16(flags >> iouring_syscall.IORING_CQE_BUFFER_SHIFT))
+}
+
+// ProvideBuffers provides nr buffers of the b to the buffer group,
+// each buffer is len(b)/nr bytes, and buffer ids start from bid
+func ProvideBuffers(b []byte, nr int, group uint16, bid uint16) PrepRequest {
+	var bp unsafe.Pointer
+	if len(b) > 0 {
+		bp = unsafe.Pointer(&b[0])
+	} else {
+		bp = unsafe.Pointer(&_zero)
+	}
+
+	var size int
+	if nr > 0 {
+		size = len(b) / nr
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = errResolver
+		userData.SetRequestBuffer(b, nil)
+
+		sqe.PrepOperation(
+			iouring_syscall.IORING_OP_PROVIDE_BUFFERS,
+			int32(nr),
+			uint64(uintptr(bp)),
+			uint32(size),
+			uint64(bid),
+		)
+		sqe.SetBufGroup(group)
+	}
+}
+
+// RemoveBuffers removes nr buffers from the buffer group,
+// the result value is the number of buffers removed
+func RemoveBuffers(nr int, group uint16) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = fdResolver
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_REMOVE_BUFFERS, int32(nr), 0, 0, 0)
+		sqe.SetBufGroup(group)
+	}
+}
+
+// ReadWithBufferGroup reads into a buffer selected from the group when the data is ready,
+// Result.GetRequestBuffer returns the read data in the selected buffer,
+// and Result.FreeRequestBuffer gives the buffer back to the group
+func ReadWithBufferGroup(fd int, group ProvidedBuffers) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+		userData.request.group = group
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_READ, int32(fd), 0, uint32(group.BufferSize()), 0)
+		sqe.SetFlags(iouring_syscall.IOSQE_FLAGS_BUFFER_SELECT)
+		sqe.SetBufGroup(group.ID())
+	}
+}
+
+// RecvWithBufferGroup receives into a buffer selected from the group when the data is ready,
+// Result.GetRequestBuffer returns the received data in the selected buffer,
+// and Result.FreeRequestBuffer gives the buffer back to the group
+func RecvWithBufferGroup(sockfd int, group ProvidedBuffers, flags int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+		userData.request.group = group
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_RECV, int32(sockfd), 0, uint32(group.BufferSize()), 0)
+		sqe.SetOpFlags(uint32(flags))
+		sqe.SetFlags(iouring_syscall.IOSQE_FLAGS_BUFFER_SELECT)
+		sqe.SetBufGroup(group.ID())
+	}
+}
+
+// RecvMultishot posts a result for every received chunk of data in a buffer selected from the group,
+// until EOF, cancellation or an error such as ENOBUFS when the group runs out of buffers.
+// Result.HasMore reports whether more results will follow, and Result.BufferID returns the selected buffer
+// Available since 6.0
+func RecvMultishot(sockfd int, group ProvidedBuffers, flags int) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+		userData.request.group = group
+
+		sqe.PrepOperation(iouring_syscall.IORING_OP_RECV, int32(sockfd), 0, 0, 0)
+		sqe.SetOpFlags(uint32(flags))
+		sqe.SetIoprio(iouring_syscall.IORING_RECV_MULTISHOT)
+		sqe.SetFlags(iouring_syscall.IOSQE_FLAGS_BUFFER_SELECT)
+		sqe.SetBufGroup(group.ID())
+	}
+}
@@ -0,0 +1,78 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"errors"
+	"syscall"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// Raw mode APIs are used by the ring created with WithRawMode,
+// they are not safe for concurrent use, and must not be used after the ring is closed
+
+// GetSQE returns a free entry of the submission queue, the entry is submitted by Submit.
+// return nil if the submission queue is full or the ring is not in raw mode
+func (iour *IOURing) GetSQE() iouring_syscall.SubmissionQueueEntry {
+	if !iour.raw {
+		return nil
+	}
+	return iour.sq.getSQEntry()
+}
+
+// Submit submits the entries got by GetSQE, return the number of submitted entries.
+// If it fails, the entries are kept in the submission queue and submitted by the next Submit
+func (iour *IOURing) Submit() (int, error) {
+	if !iour.raw {
+		return 0, ErrNotRawMode
+	}
+	return iour.submit()
+}
+
+// PeekCQE returns the completion event at the head of the completion queue without waiting,
+// return nil if the completion queue is empty. The event is in the completion queue,
+// it must be consumed before AdvanceCQ
+func (iour *IOURing) PeekCQE() (iouring_syscall.CompletionQueueEvent, error) {
+	if !iour.raw {
+		return nil, ErrNotRawMode
+	}
+
+	for {
+		if cqe := iour.cq.peek(); cqe != nil {
+			return cqe, nil
+		}
+
+		if !iour.sq.cqOverflow() {
+			return nil, nil
+		}
+
+		// flush the overflowed completion events into the completion queue
+		if _, err := iouring_syscall.IOURingEnter(iour.fd, 0, 0, iouring_syscall.IORING_ENTER_FLAGS_GETEVENTS, nil); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WaitCQE is like PeekCQE, but waits until there is a completion event
+func (iour *IOURing) WaitCQE() (iouring_syscall.CompletionQueueEvent, error) {
+	for {
+		cqe, err := iour.PeekCQE()
+		if cqe != nil || err != nil {
+			return cqe, err
+		}
+
+		_, err = iouring_syscall.IOURingEnter(iour.fd, 0, 1, iouring_syscall.IORING_ENTER_FLAGS_GETEVENTS, nil)
+		if err != nil && !errors.Is(err, syscall.EINTR) {
+			return nil, err
+		}
+	}
+}
+
+// AdvanceCQ marks the n completion events at the head of the completion queue as consumed
+func (iour *IOURing) AdvanceCQ(n uint32) {
+	if iour.raw {
+		iour.cq.advance(n)
+	}
+}
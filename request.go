@@ -4,6 +4,7 @@
 package iouring
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
@@ -19,8 +20,10 @@ type RequestCallback func(result Result) error
 type Request interface {
 	Result
 
+	ID() uint64
 	Cancel() (Request, error)
 	Done() <-chan struct{}
+	BufferReleased() <-chan struct{}
 
 	GetRes() (int, error)
 	// Can Only be used in ResultResolver
@@ -42,6 +45,8 @@ type Result interface {
 	ReturnExtra2() uint64
 	ReturnFd() (int, error)
 	ReturnInt() (int, error)
+	HasMore() bool
+	BufferID() (uint16, bool)
 
 	Callback() error
 }
@@ -54,6 +59,7 @@ type request struct {
 	id     uint64
 	opcode uint8
 	res    int32
+	flags  uint32
 
 	once      sync.Once
 	resolving bool
@@ -61,10 +67,14 @@ type request struct {
 
 	callback RequestCallback
 
-	fd int
-	b0 []byte
-	b1 []byte
-	bs [][]byte
+	fd     int
+	offset uint64
+	b0     []byte
+	b1     []byte
+	bs     [][]byte
+
+	group ProvidedBuffers
+	lease *FixedBufferLease
 
 	err  error
 	r0   interface{}
@@ -76,6 +86,20 @@ type request struct {
 
 	set  *requestSet
 	done chan struct{}
+
+	// closed when the kernel no longer uses the buffer of zero-copy request
+	released chan struct{}
+
+	// context of the request submitted by the Ctx variants,
+	// ctxCanceled is set when the request is canceled by the context
+	ctx         context.Context
+	ctxCanceled int32
+
+	// canceled is set by Cancel, the request which is not submitted yet is aborted by it
+	canceled int32
+
+	// short read or write is resubmitted for the remaining buffer
+	cont *continuation
 }
 
 func (req *request) resolve() {
@@ -94,15 +118,28 @@ func (req *request) resolve() {
 		req.resolver(req)
 		req.resolving = false
 
+		if req.err != nil && atomic.LoadInt32(&req.ctxCanceled) == 1 {
+			req.err = &ContextError{Err: req.ctx.Err()}
+		}
+
 		req.resolver = nil
 	})
 }
 
 func (req *request) complate(cqe iouring_syscall.CompletionQueueEvent) {
 	req.res = cqe.Result()
+	if req.cont != nil {
+		req.r1 = req.cont.transferred(req.res)
+		if req.res >= 0 {
+			req.res += req.cont.n
+		}
+	}
+	req.flags = cqe.Flags()
 	req.ext1 = cqe.Extra1()
 	req.ext2 = cqe.Extra2()
-	req.iour = nil
+	if req.group != nil && req.flags&iouring_syscall.IORING_CQE_F_BUFFER != 0 {
+		req.b0 = req.group.selectBuffer(req.flags, req.res)
+	}
 	close(req.done)
 
 	if req.set != nil {
@@ -111,6 +148,49 @@ func (req *request) complate(cqe iouring_syscall.CompletionQueueEvent) {
 	}
 }
 
+// abort completes the request which is not submitted to the kernel,
+// the request is canceled like the request of a broken link chain
+func (req *request) abort() {
+	req.res = -int32(syscall.ECANCELED)
+	if req.cont != nil {
+		req.r1 = req.cont.transferred(req.res)
+	}
+	close(req.done)
+	if req.released != nil {
+		close(req.released)
+	}
+
+	if req.set != nil {
+		req.set.complateOne()
+		req.set = nil
+	}
+}
+
+// fork returns a completed copy of the multishot request for the cqe,
+// the request itself stays in flight until the last completion event
+func (req *request) fork(cqe iouring_syscall.CompletionQueueEvent) *request {
+	result := &request{
+		id:          req.id,
+		opcode:      req.opcode,
+		resolver:    req.resolver,
+		callback:    req.callback,
+		fd:          req.fd,
+		b0:          req.b0,
+		b1:          req.b1,
+		bs:          req.bs,
+		group:       req.group,
+		requestInfo: req.requestInfo,
+		done:        make(chan struct{}),
+	}
+	result.complate(cqe)
+	return result
+}
+
+// isCanceled reports whether the request is canceled by Cancel or the context
+func (req *request) isCanceled() bool {
+	return atomic.LoadInt32(&req.canceled) == 1 || atomic.LoadInt32(&req.ctxCanceled) == 1
+}
+
 func (req *request) isDone() bool {
 	select {
 	case <-req.done:
@@ -138,13 +218,29 @@ func (req *request) Cancel() (Request, error) {
 		return nil, ErrRequestCompleted
 	}
 
+	// the flag must be set before the cancel request is submitted,
+	// the request in the later stage of a link chain is not in the kernel yet
+	atomic.StoreInt32(&req.canceled, 1)
 	return req.iour.submitCancel(req.id)
 }
 
+func (req *request) ID() uint64 {
+	return req.id
+}
+
 func (req *request) Done() <-chan struct{} {
 	return req.done
 }
 
+// BufferReleased is closed when the request buffer can be reused,
+// for zero-copy requests it may be closed after the request is done
+func (req *request) BufferReleased() <-chan struct{} {
+	if req.released != nil {
+		return req.released
+	}
+	return req.done
+}
+
 func (req *request) Opcode() uint8 {
 	return req.opcode
 }
@@ -207,7 +303,32 @@ func (req *request) ReturnInt() (int, error) {
 	return fd, nil
 }
 
+// HasMore reports whether more results of the multishot request will follow
+func (req *request) HasMore() bool {
+	return req.flags&iouring_syscall.IORING_CQE_F_MORE != 0
+}
+
+// BufferID returns the id of the buffer selected from the buffer group
+func (req *request) BufferID() (uint16, bool) {
+	if req.flags&iouring_syscall.IORING_CQE_F_BUFFER == 0 {
+		return 0, false
+	}
+	return uint16(req.flags >> iouring_syscall.IORING_CQE_BUFFER_SHIFT), true
+}
+
+// FreeRequestBuffer releases the request buffers,
+// the buffer selected from the provided buffers is given back,
+// and the leased fixed buffer is given back to the pool
 func (req *request) FreeRequestBuffer() {
+	if _, ok := req.BufferID(); ok && req.group != nil {
+		req.group.releaseSelected(req.flags)
+		req.group = nil
+	}
+	if req.lease != nil && req.isDone() {
+		req.lease.Release()
+		req.lease = nil
+	}
+
 	req.b0 = nil
 	req.b1 = nil
 	req.bs = nil
@@ -345,6 +466,20 @@ func removeTimeoutResolver(req Request) {
 	// result.res value is 0
 }
 
+func pollRemoveResolver(req Request) {
+	result := req.(*request)
+	if errResolver(result); result.err != nil {
+		switch result.err {
+		case syscall.EALREADY:
+			// poll request was found but it is already completing
+			result.err = ErrRequestCompleted
+		case syscall.ENOENT:
+			// poll request not found
+			result.err = ErrRequestNotFound
+		}
+	}
+}
+
 func cancelResolver(req Request) {
 	result := req.(*request)
 	if errResolver(result); result.err != nil {
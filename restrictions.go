@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"errors"
+	"unsafe"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// Restrictions limits the opcodes, register opcodes and sqe flags that can be used by the IOURing.
+// Request.Cancel needs the iouring_syscall.IORING_OP_ASYNC_CANCEL opcode,
+// and WithTimeout needs the iouring_syscall.IORING_OP_LINK_TIMEOUT opcode
+type Restrictions struct {
+	restrictions []iouring_syscall.IOURingRestriction
+}
+
+func NewRestrictions() *Restrictions {
+	return &Restrictions{}
+}
+
+// AllowOps allows the opcodes of requests
+func (r *Restrictions) AllowOps(ops ...uint8) *Restrictions {
+	for _, op := range ops {
+		r.add(iouring_syscall.IORING_RESTRICTION_SQE_OP, op)
+	}
+	return r
+}
+
+// AllowRegisterOps allows the opcodes of io_uring_register
+func (r *Restrictions) AllowRegisterOps(ops ...uint8) *Restrictions {
+	for _, op := range ops {
+		r.add(iouring_syscall.IORING_RESTRICTION_REGISTER_OP, op)
+	}
+	return r
+}
+
+// AllowSQEFlags allows the sqe flags, in addition to the required flags
+func (r *Restrictions) AllowSQEFlags(flags uint8) *Restrictions {
+	r.add(iouring_syscall.IORING_RESTRICTION_SQE_FLAGS_ALLOWED, flags)
+	return r
+}
+
+// RequireSQEFlags requires the sqe flags to be set on every request
+func (r *Restrictions) RequireSQEFlags(flags uint8) *Restrictions {
+	r.add(iouring_syscall.IORING_RESTRICTION_SQE_FLAGS_REQUIRED, flags)
+	return r
+}
+
+func (r *Restrictions) add(opcode uint16, arg uint8) {
+	r.restrictions = append(r.restrictions, iouring_syscall.IOURingRestriction{Opcode: opcode, Arg: arg})
+}
+
+// RegisterRestrictions registers the restrictions,
+// only allowed on the ring created by WithDisableRing and before it is enabled,
+// and the restrictions can only be registered once
+// Available since 5.10
+func (iour *IOURing) RegisterRestrictions(r *Restrictions) error {
+	if len(r.restrictions) == 0 {
+		return errors.New("restrictions is empty")
+	}
+
+	return iouring_syscall.IOURingRegister(
+		iour.fd,
+		iouring_syscall.IORING_REGISTER_RESTRICTIONS,
+		unsafe.Pointer(&r.restrictions[0]),
+		uint32(len(r.restrictions)),
+	)
+}
+
+// Enable enables the ring created by WithDisableRing, then requests can be submitted
+// Available since 5.10
+func (iour *IOURing) Enable() error {
+	iour.submitLock.Lock()
+	defer iour.submitLock.Unlock()
+
+	if !iour.disabled {
+		return nil
+	}
+
+	if err := iouring_syscall.IOURingRegister(iour.fd, iouring_syscall.IORING_REGISTER_ENABLE_RINGS, nil, 0); err != nil {
+		return err
+	}
+	iour.disabled = false
+	return nil
+}
@@ -0,0 +1,107 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"syscall"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// SendFds sends the data with the file descriptors by SCM_RIGHTS over the unix socket
+func SendFds(sockfd int, data []byte, fds []int) (PrepRequest, error) {
+	return Sendmsg(sockfd, data, syscall.UnixRights(fds...), nil, 0)
+}
+
+// RecvFds receives the data with at most maxFds file descriptors by SCM_RIGHTS over the unix socket,
+// the ReturnValue0 is the number of bytes received, and the ReturnValue1 is the received fds []int.
+// The received fds are close-on-exec, if the control message is truncated,
+// the received fds are closed and the error is ErrControlTruncated
+func RecvFds(sockfd int, buf []byte, maxFds int) (PrepRequest, error) {
+	oob := make([]byte, syscall.CmsgSpace(maxFds*4))
+	prep, err := Recvmsg(sockfd, buf, oob, nil, syscall.MSG_CMSG_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+
+	return withControlResolver(prep, oob, func(result *request, msgs []syscall.SocketControlMessage) {
+		fds := []int{}
+		for i := range msgs {
+			if msgs[i].Header.Level != syscall.SOL_SOCKET || msgs[i].Header.Type != syscall.SCM_RIGHTS {
+				continue
+			}
+			rights, err := syscall.ParseUnixRights(&msgs[i])
+			if err == nil {
+				fds = append(fds, rights...)
+			}
+		}
+
+		if result.err != nil {
+			for _, fd := range fds {
+				syscall.Close(fd)
+			}
+			return
+		}
+		result.r1 = fds
+	}), nil
+}
+
+// SendCredentials sends the data with the credentials by SCM_CREDENTIALS over the unix socket,
+// the credentials are checked by the kernel
+func SendCredentials(sockfd int, data []byte, ucred *syscall.Ucred) (PrepRequest, error) {
+	return Sendmsg(sockfd, data, syscall.UnixCredentials(ucred), nil, 0)
+}
+
+// RecvCredentials receives the data with the credentials of the sender by SCM_CREDENTIALS,
+// the SO_PASSCRED option of the unix socket must be enabled.
+// the ReturnValue0 is the number of bytes received, and the ReturnValue1 is *syscall.Ucred,
+// which is nil if there are no credentials
+func RecvCredentials(sockfd int, buf []byte) (PrepRequest, error) {
+	oob := make([]byte, syscall.CmsgSpace(syscall.SizeofUcred))
+	prep, err := Recvmsg(sockfd, buf, oob, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return withControlResolver(prep, oob, func(result *request, msgs []syscall.SocketControlMessage) {
+		if result.err != nil {
+			return
+		}
+
+		var ucred *syscall.Ucred
+		for i := range msgs {
+			if msgs[i].Header.Level != syscall.SOL_SOCKET || msgs[i].Header.Type != syscall.SCM_CREDENTIALS {
+				continue
+			}
+			if cred, err := syscall.ParseUnixCredentials(&msgs[i]); err == nil {
+				ucred = cred
+			}
+		}
+		result.r1 = ucred
+	}), nil
+}
+
+// withControlResolver parses the control messages received by the Recvmsg request,
+// the parse function is called after the resolver of Recvmsg
+func withControlResolver(prep PrepRequest, oob []byte, parse func(result *request, msgs []syscall.SocketControlMessage)) PrepRequest {
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		prep(sqe, userData)
+
+		resolver := userData.request.resolver
+		userData.request.resolver = func(req Request) {
+			resolver(req)
+
+			result := req.(*request)
+			var msgs []syscall.SocketControlMessage
+			if info, ok := result.r1.(*RecvmsgInfo); ok {
+				if info.Flags&syscall.MSG_CTRUNC != 0 {
+					result.err = ErrControlTruncated
+				}
+				msgs, _ = syscall.ParseSocketControlMessage(oob[:info.Oobn])
+			}
+			result.r1 = nil
+			parse(result, msgs)
+		}
+	}
+}
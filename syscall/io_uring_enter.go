@@ -15,8 +15,18 @@ const (
 	IORING_ENTER_FLAGS_GETEVENTS uint32 = 1 << iota
 	IORING_ENTER_FLAGS_SQ_WAKEUP
 	IORING_ENTER_FLAGS_SQ_WAIT
+	IORING_ENTER_FLAGS_EXT_ARG
 )
 
+// IOURingGeteventsArg is passed to io_uring_enter with IORING_ENTER_FLAGS_EXT_ARG,
+// Ts is the pointer of the timespec to bound the wait
+type IOURingGeteventsArg struct {
+	Sigmask     uint64
+	SigmaskSz   uint32
+	MinWaitUsec uint32
+	Ts          uint64
+}
+
 func IOURingEnter(fd int, toSubmit uint32, minComplete uint32, flags uint32, sigset *unix.Sigset_t) (int, error) {
 	res, _, errno := syscall.Syscall6(
 		SYS_IO_URING_ENTER,
@@ -36,3 +46,25 @@ func IOURingEnter(fd int, toSubmit uint32, minComplete uint32, flags uint32, sig
 
 	return int(res), nil
 }
+
+// IOURingEnterWithArg calls io_uring_enter with IORING_ENTER_FLAGS_EXT_ARG and the arg
+// Available since 5.11
+func IOURingEnterWithArg(fd int, toSubmit uint32, minComplete uint32, flags uint32, arg *IOURingGeteventsArg) (int, error) {
+	res, _, errno := syscall.Syscall6(
+		SYS_IO_URING_ENTER,
+		uintptr(fd),
+		uintptr(toSubmit),
+		uintptr(minComplete),
+		uintptr(flags|IORING_ENTER_FLAGS_EXT_ARG),
+		uintptr(unsafe.Pointer(arg)),
+		unsafe.Sizeof(*arg),
+	)
+	if errno != 0 {
+		return 0, os.NewSyscallError("iouring_enter", errno)
+	}
+	if res < 0 {
+		return 0, os.NewSyscallError("iouring_enter", syscall.Errno(-res))
+	}
+
+	return int(res), nil
+}
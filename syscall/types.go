@@ -72,6 +72,15 @@ const (
 	IORING_OP_URING_CMD
 	IORING_OP_SEND_ZC
 	IORING_OP_SENDMSG_ZC
+	IORING_OP_READ_MULTISHOT
+	IORING_OP_WAITID
+	IORING_OP_FUTEX_WAIT
+	IORING_OP_FUTEX_WAKE
+	IORING_OP_FUTEX_WAITV
+	IORING_OP_FIXED_FD_INSTALL
+	IORING_OP_FTRUNCATE
+	IORING_OP_BIND
+	IORING_OP_LISTEN
 
 	/* this goes last, obviously */
 	IORING_OP_LAST
@@ -95,14 +104,45 @@ const IOSQE_SYNC_DATASYNC uint = 1
 const IOSQE_TIMEOUT_ABS uint = 1
 const IOSQE_SPLICE_F_FD_IN_FIXED = 1 << 31
 
+// IORING_FILE_INDEX_ALLOC the kernel allocates a free slot of the registered file set
+// for the direct descriptor, otherwise the file index is slot + 1
+const IORING_FILE_INDEX_ALLOC uint32 = ^uint32(0)
+
+// accept flags, stored in the ioprio field of SubmissionQueueEntry
+const (
+	IORING_ACCEPT_MULTISHOT uint16 = 1 << iota
+)
+
+// msg ring commands, stored in the addr field of SubmissionQueueEntry
+const (
+	IORING_MSG_DATA uint64 = iota
+	IORING_MSG_SEND_FD
+)
+
+// msg ring flags, stored in the rw_flags field of SubmissionQueueEntry
+const (
+	IORING_MSG_RING_CQE_SKIP uint32 = 1 << iota
+	IORING_MSG_RING_FLAGS_PASS
+)
+
+// send and recv flags, stored in the ioprio field of SubmissionQueueEntry
+const (
+	IORING_RECVSEND_POLL_FIRST uint16 = 1 << iota
+	IORING_RECV_MULTISHOT
+	IORING_RECVSEND_FIXED_BUF
+	IORING_SEND_ZC_REPORT_USAGE
+)
+
 type SubmissionQueueEntry interface {
 	Opcode() uint8
 	Reset()
 	PrepOperation(op uint8, fd int32, addrOrSpliceOffIn uint64, len uint32, offsetOrCmdOp uint64)
 	Fd() int32
+	Offset() uint64
 	SetFdIndex(index int32)
 	SetOpFlags(opflags uint32)
 	SetUserData(userData uint64)
+	Flags() uint8
 	SetFlags(flag uint8)
 	CleanFlags(flags uint8)
 	SetIoprio(ioprio uint16)
@@ -110,6 +150,8 @@ type SubmissionQueueEntry interface {
 	SetBufGroup(bufGroup uint16)
 	SetPersonality(personality uint16)
 	SetSpliceFdIn(fdIn int32)
+	SetFileIndex(fileIndex uint32)
+	SetAddr3(addr3 uint64)
 
 	CMD(castType interface{}) interface{}
 }
@@ -125,9 +167,9 @@ type sqeCore struct {
 	opFlags  uint32
 	userdata uint64
 
-	bufIndexOrGroup uint16
-	personality     uint16
-	spliceFdIn      int32
+	bufIndexOrGroup       uint16
+	personality           uint16
+	spliceFdInOrFileIndex int32
 }
 
 func (sqe *sqeCore) Opcode() uint8 {
@@ -146,6 +188,10 @@ func (sqe *sqeCore) Fd() int32 {
 	return sqe.fd
 }
 
+func (sqe *sqeCore) Offset() uint64 {
+	return sqe.offset
+}
+
 func (sqe *sqeCore) SetFdIndex(index int32) {
 	sqe.fd = index
 	sqe.flags |= IOSQE_FLAGS_FIXED_FILE
@@ -159,12 +205,16 @@ func (sqe *sqeCore) SetUserData(userData uint64) {
 	sqe.userdata = userData
 }
 
+func (sqe *sqeCore) Flags() uint8 {
+	return sqe.flags
+}
+
 func (sqe *sqeCore) SetFlags(flags uint8) {
 	sqe.flags |= flags
 }
 
 func (sqe *sqeCore) CleanFlags(flags uint8) {
-	sqe.flags ^= flags
+	sqe.flags &^= flags
 }
 
 func (sqe *sqeCore) SetIoprio(ioprio uint16) {
@@ -184,7 +234,11 @@ func (sqe *sqeCore) SetPersonality(personality uint16) {
 }
 
 func (sqe *sqeCore) SetSpliceFdIn(fdIn int32) {
-	sqe.spliceFdIn = fdIn
+	sqe.spliceFdInOrFileIndex = fdIn
+}
+
+func (sqe *sqeCore) SetFileIndex(fileIndex uint32) {
+	sqe.spliceFdInOrFileIndex = int32(fileIndex)
 }
 
 type SubmissionQueueEntry64 struct {
@@ -197,6 +251,10 @@ func (sqe *SubmissionQueueEntry64) Reset() {
 	*sqe = SubmissionQueueEntry64{}
 }
 
+func (sqe *SubmissionQueueEntry64) SetAddr3(addr3 uint64) {
+	sqe.extra[0] = addr3
+}
+
 func (sqe *SubmissionQueueEntry64) CMD(_ interface{}) interface{} {
 	panic(fmt.Errorf("unsupported interface for CMD command"))
 }
@@ -211,6 +269,10 @@ func (sqe *SubmissionQueueEntry128) Reset() {
 	*sqe = SubmissionQueueEntry128{}
 }
 
+func (sqe *SubmissionQueueEntry128) SetAddr3(addr3 uint64) {
+	*(*uint64)(unsafe.Pointer(&sqe.cmd[0])) = addr3
+}
+
 func (sqe *SubmissionQueueEntry128) CMD(castType interface{}) interface{} {
 	return reflect.NewAt(reflect.TypeOf(castType), unsafe.Pointer(&sqe.cmd[0])).Interface()
 }
@@ -283,3 +345,22 @@ func (cqe *CompletionQueueEvent32) Clone() CompletionQueueEvent {
 
 const IORING_FSYNC_DATASYNC uint32 = 1
 const IORING_TIMEOUT_ABS uint32 = 1
+
+// CompletionQueueEvent flags
+const (
+	IORING_CQE_F_BUFFER uint32 = 1 << iota
+	IORING_CQE_F_MORE
+	IORING_CQE_F_SOCK_NONEMPTY
+	IORING_CQE_F_NOTIF
+	IORING_CQE_F_BUF_MORE
+)
+
+const IORING_CQE_BUFFER_SHIFT = 16
+
+// IORING_OP_POLL_ADD flags, stored in the len field of SubmissionQueueEntry
+const (
+	IORING_POLL_ADD_MULTI uint32 = 1 << iota
+	IORING_POLL_UPDATE_EVENTS
+	IORING_POLL_UPDATE_USER_DATA
+	IORING_POLL_ADD_LEVEL
+)
@@ -53,6 +53,24 @@ const (
 	OpMkdirat
 	OpSymlinkat
 	OpLinkat
+	OpMsgRing
+	OpFsetxattr
+	OpSetxattr
+	OpFgetxattr
+	OpGetxattr
+	OpSocket
+	OpUringCmd
+	OpSendZC
+	OpSendmsgZC
+	OpReadMultishot
+	OpWaitid
+	OpFutexWait
+	OpFutexWake
+	OpFutexWaitv
+	OpFixedFdInstall
+	OpFtruncate
+	OpBind
+	OpListen
 )
 
 // cancel operation return value
@@ -76,6 +94,13 @@ type SubmissionQueueRing interface {
 	assignQueue(ptr uintptr, len int)
 	mappedPtr() uintptr
 	index(index uint32) iouring_syscall.SubmissionQueueEntry
+
+	// makeEntry returns an entry which is not in the ring, it is copied into the ring by setEntry
+	makeEntry() iouring_syscall.SubmissionQueueEntry
+	setEntry(index uint32, sqe iouring_syscall.SubmissionQueueEntry)
+
+	// copyEntry returns a copy of the entry which is not in the ring
+	copyEntry(sqe iouring_syscall.SubmissionQueueEntry) iouring_syscall.SubmissionQueueEntry
 }
 
 func makeSubmissionQueueRing(flags uint32) SubmissionQueueRing {
@@ -119,6 +144,19 @@ func (ring *SubmissionQueueRing64) index(index uint32) iouring_syscall.Submissio
 	return &ring.queue[index]
 }
 
+func (ring *SubmissionQueueRing64) makeEntry() iouring_syscall.SubmissionQueueEntry {
+	return new(iouring_syscall.SubmissionQueueEntry64)
+}
+
+func (ring *SubmissionQueueRing64) setEntry(index uint32, sqe iouring_syscall.SubmissionQueueEntry) {
+	ring.queue[index] = *sqe.(*iouring_syscall.SubmissionQueueEntry64)
+}
+
+func (ring *SubmissionQueueRing64) copyEntry(sqe iouring_syscall.SubmissionQueueEntry) iouring_syscall.SubmissionQueueEntry {
+	entry := *sqe.(*iouring_syscall.SubmissionQueueEntry64)
+	return &entry
+}
+
 type SubmissionQueueRing128 struct {
 	queue []iouring_syscall.SubmissionQueueEntry128
 }
@@ -152,6 +190,19 @@ func (ring *SubmissionQueueRing128) index(index uint32) iouring_syscall.Submissi
 	return &ring.queue[index]
 }
 
+func (ring *SubmissionQueueRing128) makeEntry() iouring_syscall.SubmissionQueueEntry {
+	return new(iouring_syscall.SubmissionQueueEntry128)
+}
+
+func (ring *SubmissionQueueRing128) setEntry(index uint32, sqe iouring_syscall.SubmissionQueueEntry) {
+	ring.queue[index] = *sqe.(*iouring_syscall.SubmissionQueueEntry128)
+}
+
+func (ring *SubmissionQueueRing128) copyEntry(sqe iouring_syscall.SubmissionQueueEntry) iouring_syscall.SubmissionQueueEntry {
+	entry := *sqe.(*iouring_syscall.SubmissionQueueEntry128)
+	return &entry
+}
+
 type SubmissionQueue struct {
 	ptr  uintptr
 	size uint32
@@ -183,10 +234,41 @@ func (queue *SubmissionQueue) getSQEntry() iouring_syscall.SubmissionQueueEntry
 	return nil
 }
 
+// putSQEntry copies the entry made by SubmissionQueueRing.makeEntry into the queue,
+// return false if the queue is full
+func (queue *SubmissionQueue) putSQEntry(sqe iouring_syscall.SubmissionQueueEntry) bool {
+	head := atomic.LoadUint32(queue.head)
+	next := queue.sqeTail + 1
+
+	if (next - head) <= *queue.entries {
+		queue.sqes.setEntry(queue.sqeTail&*queue.mask, sqe)
+		queue.sqeTail = next
+		return true
+	}
+	return false
+}
+
+// free returns the number of entries which can be put into the queue
+func (queue *SubmissionQueue) free() uint32 {
+	return *queue.entries - (queue.sqeTail - atomic.LoadUint32(queue.head))
+}
+
 func (queue *SubmissionQueue) fallback(i uint32) {
 	queue.sqeTail -= i
 }
 
+// rollback drops the entries which are flushed but not consumed by the kernel,
+// return the number of dropped entries
+func (queue *SubmissionQueue) rollback() uint32 {
+	head := atomic.LoadUint32(queue.head)
+	n := *queue.tail - head
+
+	atomic.StoreUint32(queue.tail, head)
+	queue.sqeHead -= n
+	queue.sqeTail -= n
+	return n
+}
+
 func (queue *SubmissionQueue) cqOverflow() bool {
 	return (atomic.LoadUint32(queue.flags) & iouring_syscall.IORING_SQ_CQ_OVERFLOW) != 0
 }
@@ -0,0 +1,136 @@
+//go:build linux
+// +build linux
+
+package uringnet
+
+import (
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/iceber/iouring-go"
+)
+
+// Conn is the net.Conn whose IO is driven by the ring
+type Conn struct {
+	fd      *netFD
+	network string
+	laddr   net.Addr
+	raddr   net.Addr
+
+	readLock      sync.Mutex
+	readDeadline  *deadline
+	writeLock     sync.Mutex
+	writeDeadline *deadline
+}
+
+var _ net.Conn = &Conn{}
+
+func newConn(fd *netFD, network string, laddr, raddr net.Addr) *Conn {
+	return &Conn{
+		fd:            fd,
+		network:       network,
+		laddr:         laddr,
+		raddr:         raddr,
+		readDeadline:  newDeadline(),
+		writeDeadline: newDeadline(),
+	}
+}
+
+// Read reads data from the connection by the ring
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	result, err := c.fd.do(iouring.Read(c.fd.fd, b), c.readDeadline)
+	if err != nil {
+		return 0, c.opError("read", err)
+	}
+
+	n, _ := result.ReturnInt()
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Write writes data to the connection by the ring, it writes all the data
+// unless an error occurs or the deadline is exceeded
+func (c *Conn) Write(b []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	var written int
+	for written < len(b) {
+		result, err := c.fd.do(iouring.Write(c.fd.fd, b[written:]), c.writeDeadline)
+		if err != nil {
+			return written, c.opError("write", err)
+		}
+
+		n, _ := result.ReturnInt()
+		written += n
+	}
+	return written, nil
+}
+
+// Close closes the connection, the blocked Read and Write are unblocked and return error
+func (c *Conn) Close() error {
+	if err := c.fd.close(); err != nil {
+		return c.opError("close", err)
+	}
+	return nil
+}
+
+// CloseWrite shuts down the writing side of the connection
+func (c *Conn) CloseWrite() error {
+	var request iouring.Request
+	err := c.fd.submit(func() (err error) {
+		request, err = c.fd.ring.SubmitRequest(iouring.Shutdown(c.fd.fd, syscall.SHUT_WR), nil)
+		return
+	})
+	if err != nil {
+		return c.opError("close", err)
+	}
+	<-request.Done()
+
+	if err := request.Err(); err != nil {
+		return c.opError("close", err)
+	}
+	return nil
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.laddr
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+// SetDeadline sets the read and write deadlines, the blocked Read and Write
+// are limited by the new deadline, zero value means no deadline
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	c.writeDeadline.set(t)
+	return nil
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	return nil
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.set(t)
+	return nil
+}
+
+func (c *Conn) opError(op string, err error) error {
+	return &net.OpError{Op: op, Net: c.network, Source: c.laddr, Addr: c.raddr, Err: err}
+}
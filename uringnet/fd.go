@@ -0,0 +1,184 @@
+//go:build linux
+// +build linux
+
+package uringnet
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/iceber/iouring-go"
+)
+
+// netFD is the socket whose IO is driven by the ring
+type netFD struct {
+	fd   int
+	ring *iouring.IOURing
+
+	// submitters hold the read lock, so the fd is not closed
+	// and reused while the requests are being submitted
+	mu sync.RWMutex
+
+	closeOnce sync.Once
+	closing   chan struct{}
+}
+
+func newNetFD(fd int, ring *iouring.IOURing) *netFD {
+	return &netFD{fd: fd, ring: ring, closing: make(chan struct{})}
+}
+
+func (fd *netFD) close() error {
+	err := net.ErrClosed
+	fd.closeOnce.Do(func() {
+		// in-flight requests are canceled by their waiters,
+		// and the kernel holds the file until the requests are completed
+		close(fd.closing)
+
+		fd.mu.Lock()
+		defer fd.mu.Unlock()
+		err = os.NewSyscallError("close", syscall.Close(fd.fd))
+	})
+	return err
+}
+
+func (fd *netFD) isClosing() bool {
+	select {
+	case <-fd.closing:
+		return true
+	default:
+	}
+	return false
+}
+
+// submit calls the fn to submit the requests of the fd, the fd is not closed until the fn returns
+func (fd *netFD) submit(fn func() error) error {
+	fd.mu.RLock()
+	defer fd.mu.RUnlock()
+
+	if fd.isClosing() {
+		return net.ErrClosed
+	}
+	return fn()
+}
+
+// do submits the request and waits the result, the request is limited by the deadline with
+// the linked timeout. If the request is canceled by the timeout or the deadline is changed,
+// the deadline is checked again and the request is resubmitted
+func (fd *netFD) do(prep iouring.PrepRequest, d *deadline) (iouring.Result, error) {
+	for {
+		if fd.isClosing() {
+			return nil, net.ErrClosed
+		}
+
+		t, changed := d.get()
+		var timeout time.Duration
+		if !t.IsZero() {
+			if timeout = time.Until(t); timeout <= 0 {
+				return nil, os.ErrDeadlineExceeded
+			}
+		}
+
+		// link timeout result is not sent to the channel
+		ch := make(chan iouring.Result, 1)
+
+		var request iouring.Request
+		err := fd.submit(func() error {
+			if timeout > 0 {
+				set, err := fd.ring.SubmitRequests(prep.WithTimeout(timeout), ch)
+				if err != nil {
+					return err
+				}
+				request = set.Requests()[0]
+				return nil
+			}
+
+			var err error
+			request, err = fd.ring.SubmitRequest(prep, ch)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var result iouring.Result
+		select {
+		case result = <-ch:
+		case <-changed:
+			request.Cancel()
+			result = <-ch
+		case <-fd.closing:
+			request.Cancel()
+			result = <-ch
+		}
+
+		err = result.Err()
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, iouring.ErrRequestCanceled) && !errors.Is(err, syscall.EINTR) {
+			return nil, err
+		}
+	}
+}
+
+// deadline is changed by SetDeadline, the changed channel is closed
+// to notify the waiting request when the deadline is changed
+type deadline struct {
+	mu      sync.Mutex
+	t       time.Time
+	changed chan struct{}
+}
+
+func newDeadline() *deadline {
+	return &deadline{changed: make(chan struct{})}
+}
+
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.t = t
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+func (d *deadline) get() (time.Time, <-chan struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.t, d.changed
+}
+
+func sockaddrToAddr(network string, sa syscall.Sockaddr) net.Addr {
+	udp := strings.HasPrefix(network, "udp")
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		ip := net.IPv4(sa.Addr[0], sa.Addr[1], sa.Addr[2], sa.Addr[3])
+		if udp {
+			return &net.UDPAddr{IP: ip, Port: sa.Port}
+		}
+		return &net.TCPAddr{IP: ip, Port: sa.Port}
+	case *syscall.SockaddrInet6:
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, sa.Addr[:])
+
+		var zone string
+		if sa.ZoneId != 0 {
+			if ifi, err := net.InterfaceByIndex(int(sa.ZoneId)); err == nil {
+				zone = ifi.Name
+			}
+		}
+		if udp {
+			return &net.UDPAddr{IP: ip, Port: sa.Port, Zone: zone}
+		}
+		return &net.TCPAddr{IP: ip, Port: sa.Port, Zone: zone}
+	case *syscall.SockaddrUnix:
+		return &net.UnixAddr{Name: sa.Name, Net: network}
+	}
+	return nil
+}
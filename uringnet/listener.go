@@ -0,0 +1,160 @@
+//go:build linux
+// +build linux
+
+package uringnet
+
+import (
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/iceber/iouring-go"
+)
+
+// Listener is the net.Listener whose connections are accepted by the ring,
+// and IO of the accepted connections is driven by the ring
+type Listener struct {
+	fd      *netFD
+	network string
+	addr    net.Addr
+
+	// accept is not limited by deadline
+	deadline *deadline
+}
+
+var _ net.Listener = &Listener{}
+
+// Listen announces on the local network address, the network must be "tcp", "tcp4", "tcp6" or "unix"
+func Listen(network, address string, ring *iouring.IOURing) (net.Listener, error) {
+	var family int
+	var sa syscall.Sockaddr
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		addr, err := net.ResolveTCPAddr(network, address)
+		if err != nil {
+			return nil, &net.OpError{Op: "listen", Net: network, Err: err}
+		}
+		family, sa = ipSockaddr(network, addr.IP, addr.Port, addr.Zone)
+	case "unix":
+		family, sa = syscall.AF_UNIX, &syscall.SockaddrUnix{Name: address}
+	default:
+		return nil, &net.OpError{Op: "listen", Net: network, Err: net.UnknownNetworkError(network)}
+	}
+
+	fd, err := listenSocket(network, family, syscall.SOCK_STREAM, sa)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: network, Err: err}
+	}
+
+	local, err := syscall.Getsockname(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, &net.OpError{Op: "listen", Net: network, Err: os.NewSyscallError("getsockname", err)}
+	}
+
+	return &Listener{
+		fd:       newNetFD(fd, ring),
+		network:  network,
+		addr:     sockaddrToAddr(network, local),
+		deadline: newDeadline(),
+	}, nil
+}
+
+// ipSockaddr returns the IPv4 address for the "tcp4" and "udp4" network or the IPv4 ip,
+// otherwise returns the IPv6 address
+func ipSockaddr(network string, ip net.IP, port int, zone string) (int, syscall.Sockaddr) {
+	ip4 := ip.To4()
+	if strings.HasSuffix(network, "4") || (!strings.HasSuffix(network, "6") && ip4 != nil && !ip.IsUnspecified()) {
+		sa := &syscall.SockaddrInet4{Port: port}
+		if ip4 != nil {
+			copy(sa.Addr[:], ip4)
+		}
+		return syscall.AF_INET, sa
+	}
+
+	sa := &syscall.SockaddrInet6{Port: port}
+	if ip != nil && !ip.IsUnspecified() {
+		copy(sa.Addr[:], ip.To16())
+	}
+	if zone != "" {
+		if ifi, err := net.InterfaceByName(zone); err == nil {
+			sa.ZoneId = uint32(ifi.Index)
+		}
+	}
+	return syscall.AF_INET6, sa
+}
+
+func listenSocket(network string, family, sotype int, sa syscall.Sockaddr) (int, error) {
+	fd, err := syscall.Socket(family, sotype|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return -1, os.NewSyscallError("socket", err)
+	}
+
+	if family != syscall.AF_UNIX && sotype == syscall.SOCK_STREAM {
+		if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1); err != nil {
+			syscall.Close(fd)
+			return -1, os.NewSyscallError("setsockopt", err)
+		}
+	}
+	if family == syscall.AF_INET6 {
+		// "tcp" and "udp" listen on both IPv4 and IPv6
+		v6only := 0
+		if strings.HasSuffix(network, "6") {
+			v6only = 1
+		}
+		if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_V6ONLY, v6only); err != nil {
+			syscall.Close(fd)
+			return -1, os.NewSyscallError("setsockopt", err)
+		}
+	}
+
+	if err := syscall.Bind(fd, sa); err != nil {
+		syscall.Close(fd)
+		return -1, os.NewSyscallError("bind", err)
+	}
+	if sotype == syscall.SOCK_STREAM {
+		if err := syscall.Listen(fd, syscall.SOMAXCONN); err != nil {
+			syscall.Close(fd)
+			return -1, os.NewSyscallError("listen", err)
+		}
+	}
+	return fd, nil
+}
+
+// Accept waits for and returns the next connection accepted by the ring
+func (l *Listener) Accept() (net.Conn, error) {
+	result, err := l.fd.do(iouring.Accept4(l.fd.fd, syscall.SOCK_CLOEXEC), l.deadline)
+	if err != nil {
+		return nil, &net.OpError{Op: "accept", Net: l.network, Addr: l.addr, Err: err}
+	}
+
+	fd, _ := result.ReturnFd()
+	var remote net.Addr
+	if sa, ok := result.ReturnValue1().(syscall.Sockaddr); ok {
+		remote = sockaddrToAddr(l.network, sa)
+	}
+
+	var local net.Addr
+	if sa, err := syscall.Getsockname(fd); err == nil {
+		local = sockaddrToAddr(l.network, sa)
+	}
+	return newConn(newNetFD(fd, l.fd.ring), l.network, local, remote), nil
+}
+
+// Close stops listening, the blocked Accept is unblocked and returns error
+func (l *Listener) Close() error {
+	if err := l.fd.close(); err != nil {
+		return &net.OpError{Op: "close", Net: l.network, Addr: l.addr, Err: err}
+	}
+
+	if l.network == "unix" {
+		os.Remove(l.addr.String())
+	}
+	return nil
+}
+
+// Addr returns the listener's network address
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
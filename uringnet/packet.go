@@ -0,0 +1,304 @@
+//go:build linux
+// +build linux
+
+package uringnet
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/iceber/iouring-go"
+)
+
+// PacketConn is the net.PacketConn whose datagrams are sent and received by the ring
+type PacketConn struct {
+	fd      *netFD
+	network string
+	family  int
+	laddr   net.Addr
+
+	readDeadline  *deadline
+	writeDeadline *deadline
+}
+
+var _ net.PacketConn = &PacketConn{}
+
+// Message is the datagram sent by WriteBatch
+type Message struct {
+	Buf  []byte
+	OOB  []byte
+	Addr net.Addr
+
+	// N is the number of bytes sent
+	N int
+}
+
+// ListenPacket announces on the local network address,
+// the network must be "udp", "udp4", "udp6" or "unixgram"
+func ListenPacket(network, address string, ring *iouring.IOURing) (*PacketConn, error) {
+	var family int
+	var sa syscall.Sockaddr
+	switch network {
+	case "udp", "udp4", "udp6":
+		addr, err := net.ResolveUDPAddr(network, address)
+		if err != nil {
+			return nil, &net.OpError{Op: "listen", Net: network, Err: err}
+		}
+		family, sa = ipSockaddr(network, addr.IP, addr.Port, addr.Zone)
+	case "unixgram":
+		family, sa = syscall.AF_UNIX, &syscall.SockaddrUnix{Name: address}
+	default:
+		return nil, &net.OpError{Op: "listen", Net: network, Err: net.UnknownNetworkError(network)}
+	}
+
+	fd, err := listenSocket(network, family, syscall.SOCK_DGRAM, sa)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: network, Err: err}
+	}
+
+	local, err := syscall.Getsockname(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, &net.OpError{Op: "listen", Net: network, Err: os.NewSyscallError("getsockname", err)}
+	}
+
+	return &PacketConn{
+		fd:            newNetFD(fd, ring),
+		network:       network,
+		family:        family,
+		laddr:         sockaddrToAddr(network, local),
+		readDeadline:  newDeadline(),
+		writeDeadline: newDeadline(),
+	}, nil
+}
+
+// ReadFrom reads a datagram by the ring
+func (c *PacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, _, _, addr, err := c.ReadMsg(b, nil)
+	return n, addr, err
+}
+
+// ReadMsg reads a datagram and the control messages into oob by the ring,
+// return the number of bytes copied into b, the number of bytes copied into oob,
+// the flags of the message and the source address
+func (c *PacketConn) ReadMsg(b, oob []byte) (n, oobn, flags int, addr net.Addr, err error) {
+	prep, err := iouring.Recvmsg(c.fd.fd, b, oob, nil, 0)
+	if err != nil {
+		return 0, 0, 0, nil, c.opError("read", nil, err)
+	}
+
+	result, err := c.fd.do(prep, c.readDeadline)
+	if err != nil {
+		return 0, 0, 0, nil, c.opError("read", nil, err)
+	}
+
+	n, _ = result.ReturnInt()
+	if info, ok := result.ReturnValue1().(*iouring.RecvmsgInfo); ok {
+		oobn, flags = info.Oobn, info.Flags
+		if info.From != nil {
+			addr = sockaddrToAddr(c.network, info.From)
+		}
+	}
+	return
+}
+
+// ReadMsgUDP is like ReadMsg, but the source address is *net.UDPAddr
+func (c *PacketConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	var a net.Addr
+	n, oobn, flags, a, err = c.ReadMsg(b, oob)
+	addr, _ = a.(*net.UDPAddr)
+	return
+}
+
+// WriteTo writes a datagram to the addr by the ring
+func (c *PacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	n, _, err := c.WriteMsg(b, nil, addr)
+	return n, err
+}
+
+// WriteMsg writes a datagram with the control messages in oob to the addr by the ring
+func (c *PacketConn) WriteMsg(b, oob []byte, addr net.Addr) (n, oobn int, err error) {
+	sa, err := c.sockaddr(addr)
+	if err != nil {
+		return 0, 0, c.opError("write", addr, err)
+	}
+
+	prep, err := iouring.Sendmsg(c.fd.fd, b, oob, sa, 0)
+	if err != nil {
+		return 0, 0, c.opError("write", addr, err)
+	}
+
+	result, err := c.fd.do(prep, c.writeDeadline)
+	if err != nil {
+		return 0, 0, c.opError("write", addr, err)
+	}
+
+	n, _ = result.ReturnInt()
+	return n, len(oob), nil
+}
+
+// WriteMsgUDP is like WriteMsg, but the addr is *net.UDPAddr
+func (c *PacketConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	return c.WriteMsg(b, oob, addr)
+}
+
+// WriteBatch writes the messages by one submission of the ring, the N of each sent message is set.
+// return the number of sent messages and the first error, the messages after the failed one
+// may be sent or not. The messages are limited by the write deadline when they are submitted
+func (c *PacketConn) WriteBatch(ms []Message) (int, error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	if c.fd.isClosing() {
+		return 0, c.opError("write", nil, net.ErrClosed)
+	}
+
+	var timeout time.Duration
+	if t, _ := c.writeDeadline.get(); !t.IsZero() {
+		if timeout = time.Until(t); timeout <= 0 {
+			return 0, c.opError("write", nil, os.ErrDeadlineExceeded)
+		}
+	}
+
+	// every message is followed by its link timeout request
+	stride := 1
+	if timeout > 0 {
+		stride = 2
+	}
+
+	preps := make([]iouring.PrepRequest, 0, len(ms)*stride)
+	for i := range ms {
+		sa, err := c.sockaddr(ms[i].Addr)
+		if err != nil {
+			return 0, c.opError("write", ms[i].Addr, err)
+		}
+
+		prep, err := iouring.Sendmsg(c.fd.fd, ms[i].Buf, ms[i].OOB, sa, 0)
+		if err != nil {
+			return 0, c.opError("write", ms[i].Addr, err)
+		}
+
+		if timeout > 0 {
+			preps = append(preps, prep.WithTimeout(timeout)...)
+		} else {
+			preps = append(preps, prep)
+		}
+	}
+
+	var set iouring.RequestSet
+	err := c.fd.submit(func() (err error) {
+		set, err = c.fd.ring.SubmitRequests(preps, nil)
+		return
+	})
+	if err != nil {
+		return 0, c.opError("write", nil, err)
+	}
+
+	select {
+	case <-set.Done():
+	case <-c.fd.closing:
+		for _, request := range set.Requests() {
+			request.Cancel()
+		}
+		<-set.Done()
+	}
+
+	var sent int
+	var firstErr error
+	requests := set.Requests()
+	for i := range ms {
+		n, err := requests[i*stride].ReturnInt()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = c.opError("write", ms[i].Addr, c.batchError(err))
+			}
+			continue
+		}
+
+		ms[i].N = n
+		sent++
+	}
+	return sent, firstErr
+}
+
+func (c *PacketConn) batchError(err error) error {
+	if !errors.Is(err, iouring.ErrRequestCanceled) {
+		return err
+	}
+
+	if c.fd.isClosing() {
+		return net.ErrClosed
+	}
+	return os.ErrDeadlineExceeded
+}
+
+// Close closes the connection, the blocked reads and writes are unblocked and return error
+func (c *PacketConn) Close() error {
+	if err := c.fd.close(); err != nil {
+		return c.opError("close", nil, err)
+	}
+
+	if c.network == "unixgram" {
+		if addr, ok := c.laddr.(*net.UnixAddr); ok && addr.Name != "" {
+			os.Remove(addr.Name)
+		}
+	}
+	return nil
+}
+
+func (c *PacketConn) LocalAddr() net.Addr {
+	return c.laddr
+}
+
+// SetDeadline sets the read and write deadlines, the blocked reads and writes
+// are limited by the new deadline, zero value means no deadline
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	c.writeDeadline.set(t)
+	return nil
+}
+
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	return nil
+}
+
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.set(t)
+	return nil
+}
+
+// sockaddr converts the destination address for the family of the socket
+func (c *PacketConn) sockaddr(addr net.Addr) (syscall.Sockaddr, error) {
+	switch addr := addr.(type) {
+	case nil:
+		return nil, nil
+	case *net.UDPAddr:
+		switch c.family {
+		case syscall.AF_INET:
+			ip4 := addr.IP.To4()
+			if ip4 == nil && addr.IP != nil {
+				return nil, &net.AddrError{Err: "non-IPv4 address", Addr: addr.String()}
+			}
+
+			sa := &syscall.SockaddrInet4{Port: addr.Port}
+			copy(sa.Addr[:], ip4)
+			return sa, nil
+		case syscall.AF_INET6:
+			_, sa := ipSockaddr("udp6", addr.IP, addr.Port, addr.Zone)
+			return sa, nil
+		}
+	case *net.UnixAddr:
+		if c.family == syscall.AF_UNIX {
+			return &syscall.SockaddrUnix{Name: addr.Name}, nil
+		}
+	}
+	return nil, syscall.EAFNOSUPPORT
+}
+
+func (c *PacketConn) opError(op string, addr net.Addr, err error) error {
+	return &net.OpError{Op: op, Net: c.network, Source: c.laddr, Addr: addr, Err: err}
+}
@@ -0,0 +1,206 @@
+package uringnet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iceber/iouring-go"
+)
+
+func TestHTTPServer(t *testing.T) {
+	ring, err := iouring.New(64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Close()
+
+	l, err := Listen("tcp", "127.0.0.1:0", ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello %s", r.URL.Path)
+	}))
+	server.Listener.Close()
+	server.Listener = l
+	server.StartTLS()
+	defer server.Close()
+
+	client := server.Client()
+	for i := 0; i < 10; i++ {
+		resp, err := client.Get(server.URL + "/uring")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "hello /uring" {
+			t.Fatalf("unexpected body: %s", body)
+		}
+	}
+}
+
+func TestConnDeadline(t *testing.T) {
+	ring, err := iouring.New(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Close()
+
+	l, err := Listen("tcp", "127.0.0.1:0", ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// blocked read is unblocked by the new deadline
+	conn.SetReadDeadline(time.Time{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		conn.SetReadDeadline(time.Now())
+	}()
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Time{})
+	if _, err := client.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := conn.Read(make([]byte, 1)); n != 1 || err != nil {
+		t.Fatalf("unexpected read: %d, %v", n, err)
+	}
+}
+
+func TestConnCloseWhileWriting(t *testing.T) {
+	ring, err := iouring.New(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Close()
+
+	l, err := Listen("tcp", "127.0.0.1:0", ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			b := make([]byte, 4096)
+			for {
+				if _, err := conn.Write(b); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the closed fd is likely reused by the pipe, and the writers must not write into it
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; !errors.Is(err, net.ErrClosed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	r.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if n, err := r.Read(make([]byte, 1)); n != 0 || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected read from the reused fd: %d, %v", n, err)
+	}
+}
+
+func TestPacketConnWriteBatch(t *testing.T) {
+	ring, err := iouring.New(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Close()
+
+	sender, err := ListenPacket("udp4", "127.0.0.1:0", ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	receiver, err := ListenPacket("udp4", "127.0.0.1:0", ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+
+	// more messages than the entries of the ring
+	ms := make([]Message, 32)
+	for i := range ms {
+		ms[i] = Message{Buf: []byte(fmt.Sprintf("message %d", i)), Addr: receiver.LocalAddr()}
+	}
+	if n, err := sender.WriteBatch(ms); n != len(ms) || err != nil {
+		t.Fatalf("unexpected write batch: %d, %v", n, err)
+	}
+
+	buf := make([]byte, 64)
+	for i := range ms {
+		n, addr, err := receiver.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf[:n]) != string(ms[i].Buf) {
+			t.Fatalf("unexpected message: %s", buf[:n])
+		}
+		if addr.String() != sender.LocalAddr().String() {
+			t.Fatalf("unexpected source address: %s", addr)
+		}
+	}
+}
@@ -12,6 +12,10 @@ type UserData struct {
 	resulter chan<- Result
 	opcode   uint8
 
+	// fd of the request is not a file descriptor of the process,
+	// it must not be replaced by the index of the registered file
+	rawFd bool
+
 	holds   []interface{}
 	request *request
 }
@@ -0,0 +1,101 @@
+//go:build linux
+// +build linux
+
+package iouring
+
+import (
+	"syscall"
+	"unsafe"
+
+	iouring_syscall "github.com/iceber/iouring-go/syscall"
+)
+
+// Getxattr gets the value of the extended attribute of the file by path,
+// the result value is the size of the attribute value, if dest is empty
+// the size is returned without the value
+// Available since 5.19
+func Getxattr(path, name string, dest []byte) (PrepRequest, error) {
+	p, err := syscall.ByteSliceFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err := syscall.ByteSliceFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = fdResolver
+		userData.hold(&p, &n, &dest)
+
+		prepXattr(sqe, iouring_syscall.IORING_OP_GETXATTR, 0, n, dest, 0)
+		sqe.SetAddr3(uint64(uintptr(unsafe.Pointer(&p[0]))))
+	}, nil
+}
+
+// Setxattr sets the value of the extended attribute of the file by path
+// Available since 5.19
+func Setxattr(path, name string, data []byte, flags int) (PrepRequest, error) {
+	p, err := syscall.ByteSliceFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err := syscall.ByteSliceFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.rawFd = true
+		userData.request.resolver = errResolver
+		userData.hold(&p, &n, &data)
+
+		prepXattr(sqe, iouring_syscall.IORING_OP_SETXATTR, 0, n, data, flags)
+		sqe.SetAddr3(uint64(uintptr(unsafe.Pointer(&p[0]))))
+	}, nil
+}
+
+// Fgetxattr gets the value of the extended attribute of the fd,
+// the result value is the size of the attribute value, if dest is empty
+// the size is returned without the value
+// Available since 5.19
+func Fgetxattr(fd int, name string, dest []byte) (PrepRequest, error) {
+	n, err := syscall.ByteSliceFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = fdResolver
+		userData.hold(&n, &dest)
+
+		prepXattr(sqe, iouring_syscall.IORING_OP_FGETXATTR, fd, n, dest, 0)
+	}, nil
+}
+
+// Fsetxattr sets the value of the extended attribute of the fd
+// Available since 5.19
+func Fsetxattr(fd int, name string, data []byte, flags int) (PrepRequest, error) {
+	n, err := syscall.ByteSliceFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(sqe iouring_syscall.SubmissionQueueEntry, userData *UserData) {
+		userData.request.resolver = errResolver
+		userData.hold(&n, &data)
+
+		prepXattr(sqe, iouring_syscall.IORING_OP_FSETXATTR, fd, n, data, flags)
+	}, nil
+}
+
+func prepXattr(sqe iouring_syscall.SubmissionQueueEntry, op uint8, fd int, name []byte, value []byte, flags int) {
+	var v uint64
+	if len(value) > 0 {
+		v = uint64(uintptr(unsafe.Pointer(&value[0])))
+	}
+
+	sqe.PrepOperation(op, int32(fd), uint64(uintptr(unsafe.Pointer(&name[0]))), uint32(len(value)), v)
+	sqe.SetOpFlags(uint32(flags))
+}